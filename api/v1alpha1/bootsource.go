@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// ErrNoRootBootSource is returned when the VM template has no root boot source.
+var ErrNoRootBootSource = errors.New("no root boot source configured, one of containerDisk, dataVolume, persistentVolumeClaim, clone source or PXE is required")
+
+// RootBootSources returns the root boot sources of the VM, i.e. the disks and interfaces with the lowest boot order,
+// or the first disk when no device has a boot order. The devices with a higher boot order are only tried when the
+// VM fails to boot from the first ones, so they are not root boot sources. Each source is described by its kind and
+// device, e.g. `containerDisk of disk "rootdisk"`. Disks backed by a volume that cannot hold a root filesystem are
+// ignored.
+func (t *VirtualMachineTemplateSpec) RootBootSources() []string {
+	if t.Spec.Template == nil {
+		return nil
+	}
+	vmiSpec := t.Spec.Template.Spec
+	devices := vmiSpec.Domain.Devices
+
+	type bootSource struct {
+		description string
+		order       uint
+	}
+	bootSources := []bootSource{}
+	for _, disk := range devices.Disks {
+		if disk.BootOrder == nil {
+			continue
+		}
+		if kind := t.diskSourceKind(vmiSpec.Volumes, disk.Name); kind != "" {
+			bootSources = append(bootSources, bootSource{fmt.Sprintf("%s of disk %q", kind, disk.Name), *disk.BootOrder})
+		}
+	}
+	for _, iface := range devices.Interfaces {
+		if iface.BootOrder != nil {
+			bootSources = append(bootSources, bootSource{fmt.Sprintf("PXE of interface %q", iface.Name), *iface.BootOrder})
+		}
+	}
+
+	sources := []string{}
+	if len(bootSources) == 0 {
+		if len(devices.Disks) > 0 && !t.hasBootOrder() {
+			if kind := t.diskSourceKind(vmiSpec.Volumes, devices.Disks[0].Name); kind != "" {
+				sources = append(sources, fmt.Sprintf("%s of disk %q", kind, devices.Disks[0].Name))
+			}
+		}
+		return sources
+	}
+
+	lowest := bootSources[0].order
+	for _, source := range bootSources {
+		if source.order < lowest {
+			lowest = source.order
+		}
+	}
+	for _, source := range bootSources {
+		if source.order == lowest {
+			sources = append(sources, source.description)
+		}
+	}
+	return sources
+}
+
+// ValidateRootBootSource checks that exactly one root boot source is configured in the VM template. Several devices
+// sharing the lowest boot order are ambiguous.
+func (t *VirtualMachineTemplateSpec) ValidateRootBootSource() error {
+	sources := t.RootBootSources()
+	switch len(sources) {
+	case 0:
+		return ErrNoRootBootSource
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("conflicting root boot sources with the lowest boot order, exactly one is allowed: %s", strings.Join(sources, ", "))
+	}
+}
+
+// hasBootOrder checks if any disk or interface of the VM template has a boot order.
+func (t *VirtualMachineTemplateSpec) hasBootOrder() bool {
+	devices := t.Spec.Template.Spec.Domain.Devices
+	for _, disk := range devices.Disks {
+		if disk.BootOrder != nil {
+			return true
+		}
+	}
+	for _, iface := range devices.Interfaces {
+		if iface.BootOrder != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// diskSourceKind returns the kind of the root boot source backing the disk, or an empty string when the volume of
+// the disk is not a root boot source.
+func (t *VirtualMachineTemplateSpec) diskSourceKind(volumes []kubevirtv1.Volume, diskName string) string {
+	for _, volume := range volumes {
+		if volume.Name != diskName {
+			continue
+		}
+		switch {
+		case volume.ContainerDisk != nil:
+			return "containerDisk"
+		case volume.DataVolume != nil:
+			return t.dataVolumeSourceKind(volume.DataVolume.Name, "dataVolume")
+		case volume.PersistentVolumeClaim != nil:
+			return t.dataVolumeSourceKind(volume.PersistentVolumeClaim.ClaimName, "persistentVolumeClaim")
+		case volume.Ephemeral != nil && volume.Ephemeral.PersistentVolumeClaim != nil:
+			return "persistentVolumeClaim"
+		}
+		return ""
+	}
+	return ""
+}
+
+// dataVolumeSourceKind returns "clone source" for a claim created by a DataVolumeTemplate cloning a PVC, and the
+// given kind otherwise.
+func (t *VirtualMachineTemplateSpec) dataVolumeSourceKind(claimName, kind string) string {
+	for _, dataVolume := range t.Spec.DataVolumeTemplates {
+		if dataVolume.Name != claimName {
+			continue
+		}
+		if dataVolume.Spec.Source != nil && dataVolume.Spec.Source.PVC != nil {
+			return "clone source"
+		}
+		return "dataVolume"
+	}
+	return kind
+}
@@ -33,6 +33,50 @@ const (
 	// WaitingForBootstrapDataReason (Severity=Info) documents a KubevirtMachine waiting for the bootstrap
 	// script to be ready before starting to create the VM that provides the KubevirtMachine infrastructure.
 	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
+
+	// ControlPlaneInitializationStuckReason (Severity=Warning) documents a worker KubevirtMachine that waited
+	// longer than the configured timeout for the control plane to be initialized.
+	ControlPlaneInitializationStuckReason = "ControlPlaneInitializationStuck"
+
+	// PriorityClassNotFoundReason (Severity=Warning) documents a KubevirtMachine referencing a PriorityClass
+	// that does not exist in the infra cluster, which prevents creating the VM until the PriorityClass is created.
+	PriorityClassNotFoundReason = "PriorityClassNotFound"
+
+	// InfraResourceNamesUnknownReason (Severity=Warning) documents a deleted KubevirtMachine whose VM cannot be found,
+	// as the infra resource name prefix is neither recorded in its status nor available from the KubevirtCluster.
+	InfraResourceNamesUnknownReason = "InfraResourceNamesUnknown"
+
+	// InfraNamespaceTerminatingReason (Severity=Warning) documents a KubevirtMachine whose VM namespace in the
+	// infra cluster is being deleted, which prevents creating the VM, or delays the deletion of the machine until
+	// the namespace controller deleted the VM.
+	InfraNamespaceTerminatingReason = "InfraNamespaceTerminating"
+
+	// FlavorNotFoundReason (Severity=Warning) documents a KubevirtMachine whose VM references a flavor that does
+	// not exist in the infra cluster, which prevents creating the VM until the flavor is created.
+	FlavorNotFoundReason = "FlavorNotFound"
+
+	// NetworkInterfaceMultiqueueUnsupportedReason (Severity=Error) documents a KubevirtMachine enabling the automatic
+	// network interface multi-queue on a VM with a single vCPU, which prevents creating the VM until the spec is fixed.
+	NetworkInterfaceMultiqueueUnsupportedReason = "NetworkInterfaceMultiqueueUnsupported"
+
+	// AgentDisconnectedReason (Severity=Warning) documents a KubevirtMachine whose VM is running without its guest
+	// agent connected, while the KubevirtCluster requires the guest agent to be connected.
+	AgentDisconnectedReason = "AgentDisconnected"
+
+	// WaitingForGlobalCapacityReason (Severity=Info) documents a KubevirtMachine whose VM creation is held back, as
+	// the maximum number of VMs provisioned at once by the controller across all clusters is reached.
+	WaitingForGlobalCapacityReason = "WaitingForGlobalCapacity"
+
+	// VMProvisioningReason (Severity=Info) documents a KubevirtMachine whose VM is created and not ready yet.
+	VMProvisioningReason = "VMProvisioning"
+
+	// WaitingForStartupProbeReason (Severity=Info) documents a KubevirtMachine whose VM is not ready yet, while a
+	// startup probe is configured to wait for a slow booting guest.
+	WaitingForStartupProbeReason = "WaitingForStartupProbe"
+
+	// WaitingForPreTerminateHookReason (Severity=Info) documents a deleted KubevirtMachine whose VM deletion waits
+	// for the pre-terminate hook annotations of the Machine to be removed by external controllers.
+	WaitingForPreTerminateHookReason = "WaitingForPreTerminateHook"
 )
 
 const (
@@ -50,6 +94,44 @@ const (
 	// bootstrapping the Kubernetes node on the machine just provisioned; those kind of errors are usually
 	// transient and failed bootstrap are automatically re-tried by the controller.
 	BootstrapFailedReason = "BootstrapFailed"
+
+	// BootstrapRebootedReason documents (Severity=Info) a KubevirtMachine whose VM was rebooted to recover from a
+	// failing bootstrap, as configured by its bootstrap failure recovery.
+	BootstrapRebootedReason = "BootstrapRebooted"
+
+	// BootstrapDataRotatedReason (Severity=Info) documents a KubevirtMachine whose VM is recreated, as its bootstrap
+	// data was rotated before the VM bootstrapped.
+	BootstrapDataRotatedReason = "BootstrapDataRotated"
+)
+
+const (
+	// ThrottledByInfraAPICondition documents the requests of the controller to the infra cluster of a KubevirtMachine
+	// being delayed by the client-side rate limiter. The condition is only set while the requests are throttled.
+	ThrottledByInfraAPICondition clusterv1.ConditionType = "ThrottledByInfraAPI"
+
+	// ClientSideRateLimitedReason documents requests to the infra cluster waiting for the client-side rate limiter;
+	// the rate limit of an external infra cluster can be raised with the --infra-cluster-qps and --infra-cluster-burst
+	// flags of the controller.
+	ClientSideRateLimitedReason = "ClientSideRateLimited"
+)
+
+const (
+	// NodeHealthyCondition reports the presence and the readiness of the workload cluster node of a KubevirtMachine,
+	// when the KubevirtCluster reports node conditions, e.g. for MachineHealthChecks, or the KubevirtMachine has a
+	// node health monitor.
+	NodeHealthyCondition clusterv1.ConditionType = "NodeHealthy"
+
+	// NodeNotReadyReason documents a workload cluster node whose Ready condition is not true. The severity is Info
+	// while a node health monitor waits for the unhealthy timeout, and Warning otherwise.
+	NodeNotReadyReason = "NodeNotReady"
+
+	// NodeUnhealthyReason (Severity=Warning) documents a workload cluster node not ready for longer than the
+	// unhealthy timeout of the node health monitor of the KubevirtMachine.
+	NodeUnhealthyReason = "NodeUnhealthy"
+
+	// NodeNotFoundReason (Severity=Warning) documents a provisioned KubevirtMachine whose workload cluster node is
+	// missing.
+	NodeNotFoundReason = "NodeNotFound"
 )
 
 // Conditions and condition Reasons for the KubevirtCluster object
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
@@ -57,8 +58,140 @@ type KubevirtClusterSpec struct {
 	// InfraClusterSecretRef is a reference to a secret with a kubeconfig for external cluster used for infra.
 	// +optional
 	InfraClusterSecretRef *corev1.ObjectReference `json:"infraClusterSecretRef,omitempty"`
+
+	// ControlPlaneInitializationTimeout is the time worker machines wait for the control plane to be
+	// initialized before reporting the control plane as stuck. When nil, workers keep waiting without
+	// escalating the condition.
+	// +optional
+	ControlPlaneInitializationTimeout *metav1.Duration `json:"controlPlaneInitializationTimeout,omitempty"`
+
+	// InfraResourceNamePrefix is prepended, followed by a dash, to the names of all objects created in the infra
+	// cluster for this cluster: VMs, userdata secrets, DataVolumes and services. It allows clusters of several
+	// management clusters to share one infra namespace without name collisions. The prefix is immutable.
+	// +optional
+	InfraResourceNamePrefix string `json:"infraResourceNamePrefix,omitempty"`
+
+	// BootstrapMarkers are the stages of the node bootstrap, in the order they complete. Each stage is marked as
+	// completed by a file created in the guest, e.g. by a bootstrap command. They are checked in sequence, and the
+	// bootstrap progress is reported in the BootstrapExecSucceeded condition of the machines.
+	// +optional
+	BootstrapMarkers []BootstrapMarker `json:"bootstrapMarkers,omitempty"`
+
+	// TrustBundle are additional CA certificates installed in the system trust store of the nodes before they
+	// bootstrap, e.g. to pull images from a registry or through a proxy using a private CA. The certificates
+	// are injected in the cloud-init or Ignition user data of the nodes.
+	// +optional
+	TrustBundle []TrustBundleSource `json:"trustBundle,omitempty"`
+
+	// MachineNotificationWebhook, when set, is notified whenever a machine of the cluster becomes ready or fails
+	// to be provisioned.
+	// +optional
+	MachineNotificationWebhook *MachineNotificationWebhook `json:"machineNotificationWebhook,omitempty"`
+
+	// DiskCacheMode, when set, is enforced on all the DataVolume backed disks of the machines' VMs, overriding the
+	// cache mode set on the disks of the VirtualMachineTemplate, which is reported by a DiskCacheModeOverridden
+	// warning event on the KubevirtMachine. Some storage backends require the none cache mode, i.e. O_DIRECT access,
+	// to keep the data consistent. When empty, the cache mode of each disk is left as is.
+	// +kubebuilder:validation:Enum=none;writethrough
+	// +optional
+	DiskCacheMode kubevirtv1.DriverCache `json:"diskCacheMode,omitempty"`
+
+	// GuestAgentPolicy defines whether the QEMU guest agent of the VMs must be connected for the machines to be
+	// ready. When Required, a machine whose guest agent is not connected is not ready, and its VMProvisioned
+	// condition reports the AgentDisconnected reason. Defaults to Optional.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	GuestAgentPolicy GuestAgentPolicy `json:"guestAgentPolicy,omitempty"`
+
+	// DisableSSHKeyInjection, when true, disables the generation of the cluster SSH keys and their injection in the
+	// user data of the nodes. The bootstrap of the nodes is then not checked over SSH, e.g. for clusters relying on
+	// KubeVirt accessCredentials or on the node registration to verify the nodes. Defaults to false.
+	// +optional
+	DisableSSHKeyInjection bool `json:"disableSSHKeyInjection,omitempty"`
+
+	// NodeDeletionMode defines what is done to the workload cluster node of a machine before its VM is deleted.
+	// When CordonOnly, the node is cordoned so that no new pods are scheduled on it, without evicting the pods it
+	// runs, e.g. to recycle workers faster than a full drain allows. Defaults to None, leaving the node as is.
+	// +kubebuilder:validation:Enum=None;CordonOnly
+	// +optional
+	NodeDeletionMode NodeDeletionMode `json:"nodeDeletionMode,omitempty"`
+
+	// DefaultNodeLabels are labels set on the workload cluster nodes of all the machines of the cluster, e.g. an
+	// environment label. The labels the machines set on their nodes, like the instance type label or the node labels
+	// of the KubevirtMachine, take precedence.
+	// +optional
+	DefaultNodeLabels map[string]string `json:"defaultNodeLabels,omitempty"`
+
+	// NodeMatchStrategy defines how the workload cluster node of a machine is found, to set its providerID. When
+	// Hostname, the node registered with the hostname of the VM, or its FQDN when the VM has a subdomain, is matched,
+	// e.g. for nodes not named after the VM. When IP, the node with the internal IP address of the VM is matched.
+	// Defaults to Name, matching the node named after the machine.
+	// +kubebuilder:validation:Enum=Name;Hostname;IP
+	// +optional
+	NodeMatchStrategy NodeMatchStrategy `json:"nodeMatchStrategy,omitempty"`
+
+	// ReportNodeConditions, when true, reports the health of the workload cluster nodes on the NodeHealthy
+	// condition of the provisioned machines: the condition is false with the NodeNotFound reason when the node is
+	// missing, and with the NodeNotReady reason when the node is not ready. Defaults to false.
+	// +optional
+	ReportNodeConditions bool `json:"reportNodeConditions,omitempty"`
+
+	// SSHAddressMode defines the address the bootstrap of the VMs is checked at over SSH. When PodIP, the IP of the
+	// virt-launcher pod of the VM is used, reaching the VM over the pod network, e.g. for VMs with a masquerade
+	// interface and no externally reachable address. Defaults to Interface, using the address of the first interface
+	// of the VM.
+	// +kubebuilder:validation:Enum=Interface;PodIP
+	// +optional
+	SSHAddressMode SSHAddressMode `json:"sshAddressMode,omitempty"`
 }
 
+// GuestAgentPolicy defines whether the guest agent of the VMs is required for the machines to be ready.
+type GuestAgentPolicy string
+
+const (
+	// GuestAgentOptional ignores the guest agent connectivity of the VMs.
+	GuestAgentOptional GuestAgentPolicy = "Optional"
+
+	// GuestAgentRequired requires the guest agent of the VMs to be connected for the machines to be ready.
+	GuestAgentRequired GuestAgentPolicy = "Required"
+)
+
+// NodeDeletionMode defines what is done to the workload cluster node of a machine before its VM is deleted.
+type NodeDeletionMode string
+
+const (
+	// NodeDeletionNone leaves the workload cluster node as is.
+	NodeDeletionNone NodeDeletionMode = "None"
+
+	// NodeDeletionCordonOnly cordons the workload cluster node, without draining it.
+	NodeDeletionCordonOnly NodeDeletionMode = "CordonOnly"
+)
+
+// NodeMatchStrategy defines how the workload cluster node of a machine is found.
+type NodeMatchStrategy string
+
+const (
+	// NodeMatchByName matches the node named after the machine.
+	NodeMatchByName NodeMatchStrategy = "Name"
+
+	// NodeMatchByHostname matches the node registered with the hostname of the VM.
+	NodeMatchByHostname NodeMatchStrategy = "Hostname"
+
+	// NodeMatchByIP matches the node with the internal IP address of the VM.
+	NodeMatchByIP NodeMatchStrategy = "IP"
+)
+
+// SSHAddressMode defines the address the bootstrap of the VMs is checked at over SSH.
+type SSHAddressMode string
+
+const (
+	// SSHAddressInterface uses the address of the first interface of the VM.
+	SSHAddressInterface SSHAddressMode = "Interface"
+
+	// SSHAddressPodIP uses the IP of the virt-launcher pod of the VM.
+	SSHAddressPodIP SSHAddressMode = "PodIP"
+)
+
 // KubevirtClusterStatus defines the observed state of KubevirtCluster.
 type KubevirtClusterStatus struct {
 	// Ready denotes that the infrastructure is ready.
@@ -95,6 +228,36 @@ type SSHKeys struct {
 	DataSecretName *string `json:"dataSecretName,omitempty"`
 }
 
+// BootstrapMarker describes a stage of the node bootstrap.
+type BootstrapMarker struct {
+	// Name of the bootstrap stage, e.g. "containerd ready".
+	Name string `json:"name"`
+
+	// Path of the file created in the guest once the stage is completed. It must be absolute.
+	Path string `json:"path"`
+}
+
+// TrustBundleSource is a source of PEM encoded CA certificates. Exactly one of PEM and SecretRef must be set.
+type TrustBundleSource struct {
+	// PEM holds PEM encoded CA certificates.
+	// +optional
+	PEM string `json:"pem,omitempty"`
+
+	// SecretRef selects a key of a secret in the namespace of the KubevirtCluster holding PEM encoded
+	// CA certificates.
+	// +optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// MachineNotificationWebhook describes the webhook notified about machine provisioning.
+// The webhook receives a JSON POST request with the event ("Ready" or "Failed"), the namespace and name of the
+// KubevirtMachine, its providerID and addresses. A notification failing to be delivered is retried a few times
+// before being dropped, and never blocks the reconciliation of the machine.
+type MachineNotificationWebhook struct {
+	// URL of the webhook, e.g. https://automation.example.com/machines.
+	URL string `json:"url"`
+}
+
 // ControlPlaneServiceTemplate describes the template for the control plane service.
 type ControlPlaneServiceTemplate struct {
 	// Service metadata allows to set labels and annotations for the service.
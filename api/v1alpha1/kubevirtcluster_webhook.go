@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (c *KubevirtCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha1-kubevirtcluster,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=kubevirtclusters,versions=v1alpha1,name=validation.kubevirtcluster.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &KubevirtCluster{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (c *KubevirtCluster) ValidateCreate() error {
+	return c.validateBootstrapMarkers()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (c *KubevirtCluster) ValidateUpdate(old runtime.Object) error {
+	oldCluster := old.(*KubevirtCluster)
+	// The objects created in the infra cluster would no longer be found under a new prefix.
+	if c.Spec.InfraResourceNamePrefix != oldCluster.Spec.InfraResourceNamePrefix {
+		return errors.New("infraResourceNamePrefix is immutable")
+	}
+	return c.validateBootstrapMarkers()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (c *KubevirtCluster) ValidateDelete() error {
+	return nil
+}
+
+// validateBootstrapMarkers checks that the bootstrap markers are absolute paths, as they are checked in the guest
+// from the home directory of the SSH user.
+func (c *KubevirtCluster) validateBootstrapMarkers() error {
+	for _, marker := range c.Spec.BootstrapMarkers {
+		if !path.IsAbs(marker.Path) {
+			return fmt.Errorf("path %q of bootstrap marker %q must be absolute", marker.Path, marker.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("KubevirtCluster Validation", func() {
+	newCluster := func(prefix string) *KubevirtCluster {
+		return &KubevirtCluster{Spec: KubevirtClusterSpec{InfraResourceNamePrefix: prefix}}
+	}
+
+	It("should accept updates keeping the infra resource name prefix", func() {
+		cluster := newCluster("mgmt1")
+		cluster.Spec.InfraClusterSecretRef = &corev1.ObjectReference{Namespace: "infra", Name: "infra-kubeconfig"}
+		Expect(cluster.ValidateUpdate(newCluster("mgmt1"))).To(Succeed())
+	})
+
+	DescribeTable("should reject changes of the infra resource name prefix",
+		func(oldPrefix, newPrefix string) {
+			Expect(newCluster(newPrefix).ValidateUpdate(newCluster(oldPrefix))).To(MatchError(ContainSubstring("infraResourceNamePrefix is immutable")))
+		},
+		Entry("set", "", "mgmt1"),
+		Entry("changed", "mgmt1", "mgmt2"),
+		Entry("removed", "mgmt1", ""),
+	)
+
+	It("should accept absolute bootstrap marker paths", func() {
+		cluster := newCluster("")
+		cluster.Spec.BootstrapMarkers = []BootstrapMarker{{Name: "bootstrap", Path: "/run/cluster-api/bootstrap-success.complete"}}
+		Expect(cluster.ValidateCreate()).To(Succeed())
+		Expect(cluster.ValidateUpdate(newCluster(""))).To(Succeed())
+	})
+
+	It("should reject relative bootstrap marker paths", func() {
+		cluster := newCluster("")
+		cluster.Spec.BootstrapMarkers = []BootstrapMarker{{Name: "bootstrap", Path: "bootstrap-success.complete; reboot"}}
+		Expect(cluster.ValidateCreate()).To(MatchError(ContainSubstring("must be absolute")))
+		Expect(cluster.ValidateUpdate(newCluster(""))).To(MatchError(ContainSubstring("must be absolute")))
+	})
+})
@@ -18,9 +18,13 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
 const (
@@ -50,6 +54,223 @@ type KubevirtMachineSpec struct {
 	// When nil, this defaults to the value present in the KubevirtCluster object's spec associated with this machine.
 	// +optional
 	InfraClusterSecretRef *corev1.ObjectReference `json:"infraClusterSecretRef,omitempty"`
+
+	// NodeInstanceType, when set, makes the controller label the workload cluster node with
+	// the node.kubernetes.io/instance-type label. When nil, the label is not managed by the controller.
+	// +optional
+	NodeInstanceType *NodeInstanceType `json:"nodeInstanceType,omitempty"`
+
+	// NodeLabels are labels set on the workload cluster node of the machine. They take precedence over the
+	// default node labels of the KubevirtCluster and over the instance type label.
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+
+	// PriorityClassName is the name of the PriorityClass applied to the VM and its launcher pod in the infra
+	// cluster. When set, it overrides the priorityClassName of the VirtualMachineTemplate. The PriorityClass
+	// must exist in the infra cluster before the VM gets created.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// KubeletExtraArgs are additional flags passed to the kubelet of the node, e.g. cloud-provider: external.
+	// They are written to the kubelet environment file of the node through the cloud-init or Ignition user data,
+	// and are passed to the kubelet along with the flags set by the bootstrap provider.
+	// +optional
+	KubeletExtraArgs map[string]string `json:"kubeletExtraArgs,omitempty"`
+
+	// Filesystems are shared into the guest with virtiofs. Each filesystem is backed by a PersistentVolumeClaim
+	// or a ConfigMap in the namespace of the VM, and is exposed to the guest with its name as the mount tag,
+	// e.g. `mount -t virtiofs <name> /mnt/<name>`.
+	// Sharing filesystems requires the ExperimentalVirtiofsSupport feature gate to be enabled in the KubeVirt
+	// installation of the infra cluster, otherwise the VM is rejected by KubeVirt.
+	// +optional
+	Filesystems []VirtiofsFilesystem `json:"filesystems,omitempty"`
+
+	// ForceTerminationTimeout is the time to wait for the VMI to terminate gracefully while deleting the machine.
+	// A VMI still terminating after this timeout is force deleted with a grace period of 0, and the machine is
+	// only removed once the VMI is gone. When nil, the machine is removed as soon as the VM deletion is issued.
+	// +optional
+	ForceTerminationTimeout *metav1.Duration `json:"forceTerminationTimeout,omitempty"`
+
+	// Inputs are input devices added to the VM, e.g. a usb tablet for proper mouse behavior in the VNC console.
+	// Supported types: tablet. Supported buses: usb, virtio. When empty, no input device is added.
+	// +optional
+	Inputs []kubevirtv1.Input `json:"inputs,omitempty"`
+
+	// OvercommitGuestOverhead, when true, excludes the memory overhead of the hypervisor from the memory request
+	// of the virt-launcher pod, so that more VMs fit on an infra node. The overhead is still consumed at runtime,
+	// so an infra node packed this way may run out of memory, and the VM may then be OOM killed. Defaults to false.
+	// +optional
+	OvercommitGuestOverhead bool `json:"overcommitGuestOverhead,omitempty"`
+
+	// NetworkInterfaceMultiqueue, when set to Auto, enables multi-queue on the network interfaces of the VM, with
+	// KubeVirt sizing the queues to the vCPU count of the VM. Auto requires a VM with at least 2 vCPUs.
+	// When empty, the setting of the VM template is kept.
+	// +kubebuilder:validation:Enum=Auto
+	// +optional
+	NetworkInterfaceMultiqueue NetworkInterfaceMultiqueueMode `json:"networkInterfaceMultiqueue,omitempty"`
+
+	// NodeHealthMonitor, when set, monitors the Ready condition of the workload cluster node of the provisioned
+	// machine in its NodeHealthy condition, and reports the machine unhealthy, with the NodeUnhealthy reason and a
+	// Warning severity, once the node has not been ready for the unhealthy timeout.
+	// +optional
+	NodeHealthMonitor *NodeHealthMonitor `json:"nodeHealthMonitor,omitempty"`
+
+	// AllowDeschedulerEviction defines whether the descheduler may evict the VM, e.g. to live migrate it while
+	// balancing the infra nodes. The descheduler.alpha.kubernetes.io/evict annotation is set on the VM pod when
+	// allowed, and removed otherwise. Defaults to false for control plane machines and true for worker machines,
+	// unless the annotation is set in the VirtualMachineTemplate.
+	// +optional
+	AllowDeschedulerEviction *bool `json:"allowDeschedulerEviction,omitempty"`
+
+	// BootstrapFailureRecovery, when set, reboots the VM once when its bootstrap keeps failing, giving a flaky first
+	// boot a chance to recover, before the bootstrap failure is escalated. The VM is rebooted at most once, until
+	// it is recreated.
+	// +optional
+	BootstrapFailureRecovery *BootstrapFailureRecovery `json:"bootstrapFailureRecovery,omitempty"`
+
+	// DiskBlockSizes are the block sizes presented to the guest for disks of the VirtualMachineTemplate, e.g. to match
+	// a storage backend with 4K native sectors.
+	// +optional
+	DiskBlockSizes []DiskBlockSize `json:"diskBlockSizes,omitempty"`
+
+	// DiskIOModes are the IO modes of disks of the VirtualMachineTemplate, e.g. native IO for disks backed by
+	// preallocated block storage.
+	// +optional
+	DiskIOModes []DiskIOMode `json:"diskIOModes,omitempty"`
+
+	// IOThreads, when set, configures the IO threads of the VM, taking precedence over the IO threads policy of the
+	// VirtualMachineTemplate. The number of IO threads can't be set, as the KubeVirt API only has the IO threads
+	// policy and the dedicated IO thread of the disks.
+	// +optional
+	IOThreads *IOThreads `json:"ioThreads,omitempty"`
+
+	// StartupProbe, when set, detects the end of the boot of slow booting guests, with a tcpSocket, httpGet or
+	// guestAgentPing handler. KubeVirt has no startup probe, so the probe is emulated: the controller runs it
+	// against the ready VM, at the address used to check the bootstrap, until it succeeds once. Each attempt times
+	// out after timeoutSeconds, at most 3 seconds. The machine is only ready from then on, and the liveness probe of
+	// the VirtualMachineTemplate is delayed until the startup probe ran out of attempts.
+	// +optional
+	StartupProbe *kubevirtv1.Probe `json:"startupProbe,omitempty"`
+
+	// FirmwareUUID is the SMBIOS UUID of the VM. It takes precedence over the firmware UUID of the
+	// VirtualMachineTemplate.
+	// +optional
+	FirmwareUUID types.UID `json:"firmwareUUID,omitempty"`
+
+	// StableFirmwareUUID, when true and no FirmwareUUID is set, derives the SMBIOS UUID of the VM from the UID of the
+	// KubevirtMachine, so that the VMs recreated for the machine keep the same UUID, e.g. for licensing. KubeVirt
+	// otherwise generates a new UUID for each VM instance. Defaults to false.
+	// +optional
+	StableFirmwareUUID bool `json:"stableFirmwareUUID,omitempty"`
+
+	// AutoattachSerialConsole defines whether the serial console is attached to the VM. When unset, the setting of
+	// the VirtualMachineTemplate is kept, leaving the KubeVirt default, which attaches the serial console, otherwise.
+	// +optional
+	AutoattachSerialConsole *bool `json:"autoattachSerialConsole,omitempty"`
+
+	// AutoattachGraphicsDevice defines whether a graphics device is attached to the VM, e.g. set to false for headless
+	// nodes. When unset, the setting of the VirtualMachineTemplate is kept, leaving the KubeVirt default, which
+	// attaches a graphics device, otherwise.
+	// +optional
+	AutoattachGraphicsDevice *bool `json:"autoattachGraphicsDevice,omitempty"`
+
+	// AutoattachMemBalloon defines whether the memory balloon device is attached to the VM. When unset, the setting
+	// of the VirtualMachineTemplate is kept, leaving the KubeVirt default, which attaches the memory balloon, otherwise.
+	// +optional
+	AutoattachMemBalloon *bool `json:"autoattachMemBalloon,omitempty"`
+}
+
+// IOThreads describes the IO threads of a VM.
+type IOThreads struct {
+	// Policy is the IO threads policy of the VM: shared to have all disks share a single IO thread, or auto to have
+	// KubeVirt allocate a pool of IO threads sized to the vCPUs of the VM.
+	// +kubebuilder:validation:Enum=shared;auto
+	Policy kubevirtv1.IOThreadsPolicy `json:"policy"`
+
+	// DedicatedDisks are the names of the disks of the VirtualMachineTemplate getting an IO thread of their own, e.g.
+	// for high IO disks.
+	// +optional
+	DedicatedDisks []string `json:"dedicatedDisks,omitempty"`
+}
+
+// DiskIOMode describes the IO mode of a disk.
+type DiskIOMode struct {
+	// Disk is the name of the disk in the VirtualMachineTemplate.
+	Disk string `json:"disk"`
+
+	// IO is the IO mode of the disk, native or threads.
+	// +kubebuilder:validation:Enum=native;threads
+	IO kubevirtv1.DriverIO `json:"io"`
+}
+
+// DiskBlockSize describes the block size presented to the guest for a disk. Either the logical and physical block
+// sizes or MatchVolume must be set.
+type DiskBlockSize struct {
+	// Disk is the name of the disk in the VirtualMachineTemplate.
+	Disk string `json:"disk"`
+
+	// Logical is the logical block size of the disk in bytes, 512 or 4096.
+	// +optional
+	Logical uint `json:"logical,omitempty"`
+
+	// Physical is the physical block size of the disk in bytes, 512 or 4096. It must not be smaller than the logical
+	// block size.
+	// +optional
+	Physical uint `json:"physical,omitempty"`
+
+	// MatchVolume, when true, presents the block size of the volume backing the disk.
+	// +optional
+	MatchVolume bool `json:"matchVolume,omitempty"`
+}
+
+// BootstrapFailureRecovery describes how a machine recovers from a failing bootstrap.
+type BootstrapFailureRecovery struct {
+	// RebootAfterFailedChecks is the number of consecutive failed bootstrap checks after which the VM is rebooted.
+	// The same number of failed checks after the reboot escalates the bootstrap failure to an error.
+	// +kubebuilder:validation:Minimum=1
+	RebootAfterFailedChecks int32 `json:"rebootAfterFailedChecks"`
+}
+
+// NodeHealthMonitor describes how the health of the workload cluster node of a machine is monitored.
+type NodeHealthMonitor struct {
+	// UnhealthyTimeout is the duration the node can be not ready before the machine is reported unhealthy.
+	UnhealthyTimeout metav1.Duration `json:"unhealthyTimeout"`
+
+	// FailMachine, when true, sets the failure reason and message of a machine reported unhealthy, so that it is
+	// remediated by a MachineHealthCheck. The failure is terminal: the machine stays failed if the node recovers.
+	// +optional
+	FailMachine bool `json:"failMachine,omitempty"`
+}
+
+// NetworkInterfaceMultiqueueMode describes how multi-queue is configured on the network interfaces of the VM.
+type NetworkInterfaceMultiqueueMode string
+
+const (
+	// NetworkInterfaceMultiqueueAuto enables multi-queue, with a queue per vCPU.
+	NetworkInterfaceMultiqueueAuto NetworkInterfaceMultiqueueMode = "Auto"
+)
+
+// NodeInstanceType describes the instance type reported on the workload cluster node.
+type NodeInstanceType struct {
+	// Name is the instance type to report on the node. When empty, the name of the flavor of the VM is
+	// reported, or without flavor a name synthesized from the CPU and memory of the VM, e.g. "kubevirt-2c-4Gi".
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// VirtiofsFilesystem describes a filesystem shared into the guest with virtiofs.
+// Exactly one of PersistentVolumeClaim and ConfigMap must be set.
+type VirtiofsFilesystem struct {
+	// Name of the filesystem. It is used as the name of the VM volume and as the virtiofs mount tag in the guest.
+	Name string `json:"name"`
+
+	// PersistentVolumeClaim is the claim shared into the guest.
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+
+	// ConfigMap is the ConfigMap shared into the guest.
+	// +optional
+	ConfigMap *corev1.LocalObjectReference `json:"configMap,omitempty"`
 }
 
 // KubevirtMachineStatus defines the observed state of KubevirtMachine.
@@ -74,6 +295,76 @@ type KubevirtMachineStatus struct {
 	// NodeUpdated denotes that the ProviderID is updated on Node of this KubevirtMachine
 	// +optional
 	NodeUpdated bool `json:"nodeupdated"`
+
+	// InfraResourceNamePrefix is the InfraResourceNamePrefix of the KubevirtCluster, recorded before the objects of
+	// the machine are created in the infra cluster, so that they are still found on deletion once the KubevirtCluster
+	// is gone.
+	// +optional
+	InfraResourceNamePrefix *string `json:"infraResourceNamePrefix,omitempty"`
+
+	// EffectiveResources are the resources of the running VM, once KubeVirt applied the memory and CPU overcommit
+	// of the infra cluster to the resources of the VirtualMachineTemplate.
+	// +optional
+	EffectiveResources *EffectiveResources `json:"effectiveResources,omitempty"`
+
+	// FailureReason will be set in the event that there is a terminal problem reconciling the machine and will
+	// contain a succinct value suitable for machine interpretation.
+	// +optional
+	FailureReason *capierrors.MachineStatusError `json:"failureReason,omitempty"`
+
+	// FailureMessage will be set in the event that there is a terminal problem reconciling the machine and will
+	// contain a more verbose string suitable for logging and human consumption.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
+
+	// BootstrapFailedChecks is the number of consecutive failed bootstrap checks of the VM, counted when a
+	// bootstrap failure recovery is configured.
+	// +optional
+	BootstrapFailedChecks int32 `json:"bootstrapFailedChecks,omitempty"`
+
+	// BootstrapRebooted denotes that the VM was rebooted to recover from a failing bootstrap.
+	// +optional
+	BootstrapRebooted bool `json:"bootstrapRebooted,omitempty"`
+
+	// StartupProbeSucceeded denotes that the startup probe of the machine succeeded since the VM was last started.
+	// +optional
+	StartupProbeSucceeded bool `json:"startupProbeSucceeded,omitempty"`
+
+	// Volumes are the DataVolumes created for the VM from the DataVolumeTemplates of the VirtualMachineTemplate,
+	// and their PersistentVolumeClaims.
+	// +optional
+	Volumes []MachineVolume `json:"volumes,omitempty"`
+}
+
+// MachineVolume describes a DataVolume created for the VM and its PersistentVolumeClaim, which has the same name.
+type MachineVolume struct {
+	// Name is the name of the DataVolume and of its PersistentVolumeClaim.
+	Name string `json:"name"`
+
+	// DataVolumePhase is the phase of the DataVolume, empty when the DataVolume does not exist.
+	// +optional
+	DataVolumePhase cdiv1.DataVolumePhase `json:"dataVolumePhase,omitempty"`
+
+	// ClaimPhase is the phase of the PersistentVolumeClaim, empty when the claim does not exist.
+	// +optional
+	ClaimPhase corev1.PersistentVolumeClaimPhase `json:"claimPhase,omitempty"`
+}
+
+// EffectiveResources describes the resources of a running VM.
+type EffectiveResources struct {
+	// CPURequest is the CPU requested for the VM from the infra cluster scheduler, i.e. the CPU requests of the
+	// containers of its virt-launcher pod.
+	// +optional
+	CPURequest *resource.Quantity `json:"cpuRequest,omitempty"`
+
+	// MemoryRequest is the memory requested for the VM from the infra cluster scheduler, i.e. the memory requests of
+	// the containers of its virt-launcher pod, including the memory overhead of the VM.
+	// +optional
+	MemoryRequest *resource.Quantity `json:"memoryRequest,omitempty"`
+
+	// GuestMemory is the memory visible to the guest, from the VMI.
+	// +optional
+	GuestMemory *resource.Quantity `json:"guestMemory,omitempty"`
 }
 
 // +kubebuilder:resource:path=kubevirtmachines,scope=Namespaced,categories=cluster-api
@@ -18,9 +18,12 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubevirtv1 "kubevirt.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -37,7 +40,19 @@ var _ webhook.Validator = &KubevirtMachineTemplate{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (m *KubevirtMachineTemplate) ValidateCreate() error {
-	return nil
+	if err := m.Spec.Template.Spec.VirtualMachineTemplate.ValidateRootBootSource(); err != nil {
+		return err
+	}
+	if err := m.Spec.Template.Spec.validateDiskBlockSizes(); err != nil {
+		return err
+	}
+	if err := m.Spec.Template.Spec.validateDiskIOModes(); err != nil {
+		return err
+	}
+	if err := m.Spec.Template.Spec.validateIOThreads(); err != nil {
+		return err
+	}
+	return m.Spec.Template.Spec.ValidateNetworkInterfaceMultiqueue()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
@@ -53,3 +68,107 @@ func (m *KubevirtMachineTemplate) ValidateUpdate(old runtime.Object) error {
 func (m *KubevirtMachineTemplate) ValidateDelete() error {
 	return nil
 }
+
+// validateDiskBlockSizes checks that the block sizes are set on disks of the VM template, with supported values.
+func (s *KubevirtMachineSpec) validateDiskBlockSizes() error {
+	disks := s.templateDisks()
+	for _, blockSize := range s.DiskBlockSizes {
+		if !disks[blockSize.Disk] {
+			return fmt.Errorf("block size set on disk %q, which is not a disk of the VM template", blockSize.Disk)
+		}
+		custom := blockSize.Logical != 0 || blockSize.Physical != 0
+		if custom == blockSize.MatchVolume {
+			return fmt.Errorf("block size of disk %q must set either the logical and physical block sizes or matchVolume", blockSize.Disk)
+		}
+		if !custom {
+			continue
+		}
+		for _, size := range []uint{blockSize.Logical, blockSize.Physical} {
+			if size != 512 && size != 4096 {
+				return fmt.Errorf("invalid block size %d of disk %q, supported block sizes are 512 and 4096", size, blockSize.Disk)
+			}
+		}
+		if blockSize.Physical < blockSize.Logical {
+			return fmt.Errorf("physical block size of disk %q must not be smaller than its logical block size", blockSize.Disk)
+		}
+	}
+	return nil
+}
+
+// validateDiskIOModes checks that the IO modes are set on disks of the VM template, with values supported by KubeVirt.
+func (s *KubevirtMachineSpec) validateDiskIOModes() error {
+	disks := s.templateDisks()
+	for _, ioMode := range s.DiskIOModes {
+		if !disks[ioMode.Disk] {
+			return fmt.Errorf("IO mode set on disk %q, which is not a disk of the VM template", ioMode.Disk)
+		}
+		if ioMode.IO != kubevirtv1.IONative && ioMode.IO != kubevirtv1.IOThreads {
+			return fmt.Errorf("invalid IO mode %q of disk %q, supported IO modes are %s and %s", ioMode.IO, ioMode.Disk, kubevirtv1.IONative, kubevirtv1.IOThreads)
+		}
+	}
+	return nil
+}
+
+// validateIOThreads checks that the IO threads policy is supported by KubeVirt, and that the dedicated IO threads are
+// set on disks of the VM template.
+func (s *KubevirtMachineSpec) validateIOThreads() error {
+	if s.IOThreads == nil {
+		return nil
+	}
+	if s.IOThreads.Policy != kubevirtv1.IOThreadsPolicyShared && s.IOThreads.Policy != kubevirtv1.IOThreadsPolicyAuto {
+		return fmt.Errorf("invalid IO threads policy %q, supported policies are %s and %s", s.IOThreads.Policy, kubevirtv1.IOThreadsPolicyShared, kubevirtv1.IOThreadsPolicyAuto)
+	}
+	disks := s.templateDisks()
+	for _, disk := range s.IOThreads.DedicatedDisks {
+		if !disks[disk] {
+			return fmt.Errorf("dedicated IO thread set on disk %q, which is not a disk of the VM template", disk)
+		}
+	}
+	return nil
+}
+
+// ValidateNetworkInterfaceMultiqueue checks that the VM has enough vCPUs for the automatic multi-queue mode, as a
+// single queue per interface brings no benefit over the default. The vCPUs of a VM sized by a flavor are only known
+// to the infra cluster, so such a VM is not checked.
+func (s *KubevirtMachineSpec) ValidateNetworkInterfaceMultiqueue() error {
+	if s.NetworkInterfaceMultiqueue != NetworkInterfaceMultiqueueAuto || s.VirtualMachineTemplate.Spec.Flavor != nil {
+		return nil
+	}
+	if cpus := s.VirtualMachineTemplate.VCPUs(); cpus < 2 {
+		return fmt.Errorf("network interface multi-queue %s requires at least 2 vCPUs, the VM has %d", NetworkInterfaceMultiqueueAuto, cpus)
+	}
+	return nil
+}
+
+// VCPUs returns the number of vCPUs of the VM, as derived by KubeVirt from the CPU topology of the domain, or from
+// the CPU request when no topology is set.
+func (t *VirtualMachineTemplateSpec) VCPUs() int64 {
+	cpus := int64(1)
+	if t.Spec.Template == nil {
+		return cpus
+	}
+	domain := t.Spec.Template.Spec.Domain
+	if domain.CPU != nil && domain.CPU.Cores > 0 {
+		cpus = int64(domain.CPU.Cores)
+		if domain.CPU.Sockets > 0 {
+			cpus *= int64(domain.CPU.Sockets)
+		}
+		if domain.CPU.Threads > 0 {
+			cpus *= int64(domain.CPU.Threads)
+		}
+	} else if cpu, ok := domain.Resources.Requests[corev1.ResourceCPU]; ok && cpu.Value() > 0 {
+		cpus = cpu.Value()
+	}
+	return cpus
+}
+
+// templateDisks returns the names of the disks of the VM template.
+func (s *KubevirtMachineSpec) templateDisks() map[string]bool {
+	disks := map[string]bool{}
+	if vmiTemplate := s.VirtualMachineTemplate.Spec.Template; vmiTemplate != nil {
+		for _, disk := range vmiTemplate.Spec.Domain.Devices.Disks {
+			disks[disk.Name] = true
+		}
+	}
+	return disks
+}
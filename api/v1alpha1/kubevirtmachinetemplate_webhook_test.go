@@ -21,6 +21,11 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 type test struct {
@@ -92,3 +97,268 @@ var _ = Describe("Template Validation", func() {
 		})
 	})
 })
+
+var _ = Describe("Root boot source validation", func() {
+	bootOrder := func(order uint) *uint { return &order }
+
+	disk := func(name string, order *uint) kubevirtv1.Disk {
+		return kubevirtv1.Disk{Name: name, BootOrder: order}
+	}
+	pxe := kubevirtv1.Interface{Name: "default", BootOrder: bootOrder(1)}
+
+	containerDiskVolume := kubevirtv1.Volume{
+		Name:         "containerdisk",
+		VolumeSource: kubevirtv1.VolumeSource{ContainerDisk: &kubevirtv1.ContainerDiskSource{Image: "node-image"}},
+	}
+	dataVolumeVolume := kubevirtv1.Volume{
+		Name:         "datavolume",
+		VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: "import"}},
+	}
+	pvcVolume := kubevirtv1.Volume{
+		Name: "pvc",
+		VolumeSource: kubevirtv1.VolumeSource{PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+			PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "existing"},
+		}},
+	}
+	cloneVolume := kubevirtv1.Volume{
+		Name:         "clone",
+		VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: "clone"}},
+	}
+	dataVolumeTemplates := []kubevirtv1.DataVolumeTemplateSpec{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "import"},
+			Spec: cdiv1.DataVolumeSpec{
+				Source: &cdiv1.DataVolumeSource{HTTP: &cdiv1.DataVolumeSourceHTTP{URL: "https://images.example.com/node.qcow2"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone"},
+			Spec: cdiv1.DataVolumeSpec{
+				Source: &cdiv1.DataVolumeSource{PVC: &cdiv1.DataVolumeSourcePVC{Namespace: "images", Name: "golden"}},
+			},
+		},
+	}
+
+	newTemplate := func(disks []kubevirtv1.Disk, interfaces []kubevirtv1.Interface, volumes ...kubevirtv1.Volume) *KubevirtMachineTemplate {
+		return &KubevirtMachineTemplate{
+			Spec: KubevirtMachineTemplateSpec{
+				Template: KubevirtMachineTemplateResource{
+					Spec: KubevirtMachineSpec{
+						VirtualMachineTemplate: VirtualMachineTemplateSpec{
+							Spec: kubevirtv1.VirtualMachineSpec{
+								DataVolumeTemplates: dataVolumeTemplates,
+								Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+									Spec: kubevirtv1.VirtualMachineInstanceSpec{
+										Domain: kubevirtv1.DomainSpec{
+											Devices: kubevirtv1.Devices{Disks: disks, Interfaces: interfaces},
+										},
+										Volumes: volumes,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	DescribeTable("should accept a single root boot source",
+		func(template *KubevirtMachineTemplate) {
+			Expect(template.ValidateCreate()).To(Succeed())
+		},
+		Entry("containerDisk", newTemplate([]kubevirtv1.Disk{disk("containerdisk", nil)}, nil, containerDiskVolume)),
+		Entry("dataVolume", newTemplate([]kubevirtv1.Disk{disk("datavolume", nil)}, nil, dataVolumeVolume)),
+		Entry("existing PVC", newTemplate([]kubevirtv1.Disk{disk("pvc", nil)}, nil, pvcVolume)),
+		Entry("clone source", newTemplate([]kubevirtv1.Disk{disk("clone", nil)}, nil, cloneVolume)),
+		Entry("PXE", newTemplate(nil, []kubevirtv1.Interface{pxe})),
+		Entry("first disk without boot order",
+			newTemplate([]kubevirtv1.Disk{disk("containerdisk", nil), disk("pvc", nil)}, nil, containerDiskVolume, pvcVolume)),
+		Entry("data disk without boot order",
+			newTemplate([]kubevirtv1.Disk{disk("pvc", nil), disk("datavolume", bootOrder(1))}, nil, pvcVolume, dataVolumeVolume)),
+		Entry("PXE before containerDisk",
+			newTemplate([]kubevirtv1.Disk{disk("containerdisk", bootOrder(2))}, []kubevirtv1.Interface{pxe}, containerDiskVolume)),
+		Entry("dataVolume before existing PVC",
+			newTemplate([]kubevirtv1.Disk{disk("datavolume", bootOrder(1)), disk("pvc", bootOrder(2))}, nil, dataVolumeVolume, pvcVolume)),
+		Entry("containerDisk before clone source",
+			newTemplate([]kubevirtv1.Disk{disk("clone", bootOrder(3)), disk("containerdisk", bootOrder(2))}, nil, cloneVolume, containerDiskVolume)),
+	)
+
+	DescribeTable("should reject conflicting root boot sources",
+		func(template *KubevirtMachineTemplate, conflicts ...string) {
+			err := template.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("conflicting root boot sources")))
+			for _, conflict := range conflicts {
+				Expect(err.Error()).To(ContainSubstring(conflict))
+			}
+		},
+		Entry("containerDisk and PXE",
+			newTemplate([]kubevirtv1.Disk{disk("containerdisk", bootOrder(1))}, []kubevirtv1.Interface{pxe}, containerDiskVolume),
+			`containerDisk of disk "containerdisk"`, `PXE of interface "default"`),
+		Entry("dataVolume and existing PVC",
+			newTemplate([]kubevirtv1.Disk{disk("datavolume", bootOrder(2)), disk("pvc", bootOrder(2))}, []kubevirtv1.Interface{{Name: "default", BootOrder: bootOrder(3)}}, dataVolumeVolume, pvcVolume),
+			`dataVolume of disk "datavolume"`, `persistentVolumeClaim of disk "pvc"`),
+		Entry("clone source and containerDisk",
+			newTemplate([]kubevirtv1.Disk{disk("clone", bootOrder(1)), disk("containerdisk", bootOrder(1))}, nil, cloneVolume, containerDiskVolume),
+			`clone source of disk "clone"`, `containerDisk of disk "containerdisk"`),
+	)
+
+	It("should only report the sources with the lowest boot order as conflicting", func() {
+		template := newTemplate([]kubevirtv1.Disk{disk("pvc", bootOrder(1)), disk("datavolume", bootOrder(1)), disk("containerdisk", bootOrder(2))}, nil, pvcVolume, dataVolumeVolume, containerDiskVolume)
+		Expect(template.Spec.Template.Spec.VirtualMachineTemplate.RootBootSources()).To(Equal([]string{
+			`persistentVolumeClaim of disk "pvc"`, `dataVolume of disk "datavolume"`,
+		}))
+	})
+
+	It("should reject a template with a boot order only on disks without root filesystem", func() {
+		cloudInitVolume := kubevirtv1.Volume{
+			Name:         "cloudinit",
+			VolumeSource: kubevirtv1.VolumeSource{CloudInitNoCloud: &kubevirtv1.CloudInitNoCloudSource{UserData: "#cloud-config"}},
+		}
+		template := newTemplate([]kubevirtv1.Disk{disk("containerdisk", nil), disk("cloudinit", bootOrder(1))}, nil, containerDiskVolume, cloudInitVolume)
+		Expect(template.ValidateCreate()).To(MatchError(ErrNoRootBootSource))
+	})
+
+	It("should reject a template without root boot source", func() {
+		Expect(newTemplate(nil, nil).ValidateCreate()).To(MatchError(ErrNoRootBootSource))
+	})
+})
+
+var _ = Describe("Disk block size validation", func() {
+	newTemplate := func(blockSize DiskBlockSize) *KubevirtMachineTemplate {
+		template := newRootDiskMachineTemplate()
+		template.Spec.Template.Spec.DiskBlockSizes = []DiskBlockSize{blockSize}
+		return template
+	}
+
+	DescribeTable("should accept supported block sizes",
+		func(blockSize DiskBlockSize) {
+			Expect(newTemplate(blockSize).ValidateCreate()).To(Succeed())
+		},
+		Entry("4K native", DiskBlockSize{Disk: "rootdisk", Logical: 4096, Physical: 4096}),
+		Entry("512 emulation", DiskBlockSize{Disk: "rootdisk", Logical: 512, Physical: 4096}),
+		Entry("volume block size", DiskBlockSize{Disk: "rootdisk", MatchVolume: true}),
+	)
+
+	DescribeTable("should reject invalid block sizes",
+		func(blockSize DiskBlockSize, message string) {
+			Expect(newTemplate(blockSize).ValidateCreate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("unsupported size", DiskBlockSize{Disk: "rootdisk", Logical: 1024, Physical: 4096}, "invalid block size 1024"),
+		Entry("missing physical size", DiskBlockSize{Disk: "rootdisk", Logical: 4096}, "invalid block size 0"),
+		Entry("physical smaller than logical", DiskBlockSize{Disk: "rootdisk", Logical: 4096, Physical: 512}, "must not be smaller"),
+		Entry("custom and volume block size", DiskBlockSize{Disk: "rootdisk", Logical: 4096, Physical: 4096, MatchVolume: true}, "either"),
+		Entry("unknown disk", DiskBlockSize{Disk: "datadisk", Logical: 4096, Physical: 4096}, "not a disk of the VM template"),
+	)
+})
+
+var _ = Describe("Disk IO mode validation", func() {
+	newTemplate := func(ioMode DiskIOMode) *KubevirtMachineTemplate {
+		template := newRootDiskMachineTemplate()
+		template.Spec.Template.Spec.DiskIOModes = []DiskIOMode{ioMode}
+		return template
+	}
+
+	DescribeTable("should accept supported IO modes",
+		func(ioMode DiskIOMode) {
+			Expect(newTemplate(ioMode).ValidateCreate()).To(Succeed())
+		},
+		Entry("native", DiskIOMode{Disk: "rootdisk", IO: kubevirtv1.IONative}),
+		Entry("threads", DiskIOMode{Disk: "rootdisk", IO: kubevirtv1.IOThreads}),
+	)
+
+	DescribeTable("should reject invalid IO modes",
+		func(ioMode DiskIOMode, message string) {
+			Expect(newTemplate(ioMode).ValidateCreate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("unsupported mode", DiskIOMode{Disk: "rootdisk", IO: "io_uring"}, `invalid IO mode "io_uring"`),
+		Entry("unknown disk", DiskIOMode{Disk: "datadisk", IO: kubevirtv1.IONative}, "not a disk of the VM template"),
+	)
+})
+
+var _ = Describe("IO threads validation", func() {
+	newTemplate := func(ioThreads *IOThreads) *KubevirtMachineTemplate {
+		template := newRootDiskMachineTemplate()
+		template.Spec.Template.Spec.IOThreads = ioThreads
+		return template
+	}
+
+	DescribeTable("should accept supported IO threads",
+		func(ioThreads *IOThreads) {
+			Expect(newTemplate(ioThreads).ValidateCreate()).To(Succeed())
+		},
+		Entry("shared", &IOThreads{Policy: kubevirtv1.IOThreadsPolicyShared}),
+		Entry("auto with a dedicated disk", &IOThreads{Policy: kubevirtv1.IOThreadsPolicyAuto, DedicatedDisks: []string{"rootdisk"}}),
+	)
+
+	DescribeTable("should reject invalid IO threads",
+		func(ioThreads *IOThreads, message string) {
+			Expect(newTemplate(ioThreads).ValidateCreate()).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("missing policy", &IOThreads{DedicatedDisks: []string{"rootdisk"}}, `invalid IO threads policy ""`),
+		Entry("unsupported policy", &IOThreads{Policy: "dedicated"}, `invalid IO threads policy "dedicated"`),
+		Entry("unknown disk", &IOThreads{Policy: kubevirtv1.IOThreadsPolicyShared, DedicatedDisks: []string{"datadisk"}}, "not a disk of the VM template"),
+	)
+})
+
+var _ = Describe("Network interface multi-queue validation", func() {
+	newTemplate := func(cpu *kubevirtv1.CPU, flavor *kubevirtv1.FlavorMatcher) *KubevirtMachineTemplate {
+		template := newRootDiskMachineTemplate()
+		template.Spec.Template.Spec.NetworkInterfaceMultiqueue = NetworkInterfaceMultiqueueAuto
+		template.Spec.Template.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.CPU = cpu
+		template.Spec.Template.Spec.VirtualMachineTemplate.Spec.Flavor = flavor
+		return template
+	}
+
+	DescribeTable("should accept VMs with enough vCPUs",
+		func(cpu *kubevirtv1.CPU, flavor *kubevirtv1.FlavorMatcher) {
+			Expect(newTemplate(cpu, flavor).ValidateCreate()).To(Succeed())
+		},
+		Entry("multiple cores", &kubevirtv1.CPU{Cores: 2}, nil),
+		Entry("multiple sockets", &kubevirtv1.CPU{Cores: 1, Sockets: 2}, nil),
+		Entry("sized by a flavor", nil, &kubevirtv1.FlavorMatcher{Name: "large"}),
+	)
+
+	DescribeTable("should reject VMs with a single vCPU",
+		func(cpu *kubevirtv1.CPU) {
+			Expect(newTemplate(cpu, nil).ValidateCreate()).To(MatchError(ContainSubstring("requires at least 2 vCPUs, the VM has 1")))
+		},
+		Entry("single core", &kubevirtv1.CPU{Cores: 1}),
+		Entry("no CPU", nil),
+	)
+
+	It("should count the requested CPUs when no topology is set", func() {
+		template := newTemplate(nil, nil)
+		template.Spec.Template.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("4"),
+		}
+		Expect(template.ValidateCreate()).To(Succeed())
+	})
+})
+
+// newRootDiskMachineTemplate returns a machine template booting from a containerDisk backed "rootdisk" disk.
+func newRootDiskMachineTemplate() *KubevirtMachineTemplate {
+	return &KubevirtMachineTemplate{
+		Spec: KubevirtMachineTemplateSpec{
+			Template: KubevirtMachineTemplateResource{
+				Spec: KubevirtMachineSpec{
+					VirtualMachineTemplate: VirtualMachineTemplateSpec{
+						Spec: kubevirtv1.VirtualMachineSpec{
+							Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+								Spec: kubevirtv1.VirtualMachineInstanceSpec{
+									Domain: kubevirtv1.DomainSpec{
+										Devices: kubevirtv1.Devices{Disks: []kubevirtv1.Disk{{Name: "rootdisk"}}},
+									},
+									Volumes: []kubevirtv1.Volume{{
+										Name:         "rootdisk",
+										VolumeSource: kubevirtv1.VolumeSource{ContainerDisk: &kubevirtv1.ContainerDiskSource{Image: "node-image"}},
+									}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -23,8 +23,11 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	corev1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/errors"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -42,6 +45,36 @@ func (in *APIEndpoint) DeepCopy() *APIEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapFailureRecovery) DeepCopyInto(out *BootstrapFailureRecovery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapFailureRecovery.
+func (in *BootstrapFailureRecovery) DeepCopy() *BootstrapFailureRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapFailureRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapMarker) DeepCopyInto(out *BootstrapMarker) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapMarker.
+func (in *BootstrapMarker) DeepCopy() *BootstrapMarker {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapMarker)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneServiceTemplate) DeepCopyInto(out *ControlPlaneServiceTemplate) {
 	*out = *in
@@ -59,6 +92,86 @@ func (in *ControlPlaneServiceTemplate) DeepCopy() *ControlPlaneServiceTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskBlockSize) DeepCopyInto(out *DiskBlockSize) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskBlockSize.
+func (in *DiskBlockSize) DeepCopy() *DiskBlockSize {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskBlockSize)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskIOMode) DeepCopyInto(out *DiskIOMode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskIOMode.
+func (in *DiskIOMode) DeepCopy() *DiskIOMode {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskIOMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveResources) DeepCopyInto(out *EffectiveResources) {
+	*out = *in
+	if in.CPURequest != nil {
+		in, out := &in.CPURequest, &out.CPURequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MemoryRequest != nil {
+		in, out := &in.MemoryRequest, &out.MemoryRequest
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.GuestMemory != nil {
+		in, out := &in.GuestMemory, &out.GuestMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveResources.
+func (in *EffectiveResources) DeepCopy() *EffectiveResources {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IOThreads) DeepCopyInto(out *IOThreads) {
+	*out = *in
+	if in.DedicatedDisks != nil {
+		in, out := &in.DedicatedDisks, &out.DedicatedDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IOThreads.
+func (in *IOThreads) DeepCopy() *IOThreads {
+	if in == nil {
+		return nil
+	}
+	out := new(IOThreads)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubevirtCluster) DeepCopyInto(out *KubevirtCluster) {
 	*out = *in
@@ -129,6 +242,35 @@ func (in *KubevirtClusterSpec) DeepCopyInto(out *KubevirtClusterSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.ControlPlaneInitializationTimeout != nil {
+		in, out := &in.ControlPlaneInitializationTimeout, &out.ControlPlaneInitializationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.BootstrapMarkers != nil {
+		in, out := &in.BootstrapMarkers, &out.BootstrapMarkers
+		*out = make([]BootstrapMarker, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrustBundle != nil {
+		in, out := &in.TrustBundle, &out.TrustBundle
+		*out = make([]TrustBundleSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MachineNotificationWebhook != nil {
+		in, out := &in.MachineNotificationWebhook, &out.MachineNotificationWebhook
+		*out = new(MachineNotificationWebhook)
+		**out = **in
+	}
+	if in.DefaultNodeLabels != nil {
+		in, out := &in.DefaultNodeLabels, &out.DefaultNodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubevirtClusterSpec.
@@ -243,6 +385,92 @@ func (in *KubevirtMachineSpec) DeepCopyInto(out *KubevirtMachineSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.NodeInstanceType != nil {
+		in, out := &in.NodeInstanceType, &out.NodeInstanceType
+		*out = new(NodeInstanceType)
+		**out = **in
+	}
+	if in.NodeLabels != nil {
+		in, out := &in.NodeLabels, &out.NodeLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubeletExtraArgs != nil {
+		in, out := &in.KubeletExtraArgs, &out.KubeletExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Filesystems != nil {
+		in, out := &in.Filesystems, &out.Filesystems
+		*out = make([]VirtiofsFilesystem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ForceTerminationTimeout != nil {
+		in, out := &in.ForceTerminationTimeout, &out.ForceTerminationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]corev1.Input, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeHealthMonitor != nil {
+		in, out := &in.NodeHealthMonitor, &out.NodeHealthMonitor
+		*out = new(NodeHealthMonitor)
+		**out = **in
+	}
+	if in.AllowDeschedulerEviction != nil {
+		in, out := &in.AllowDeschedulerEviction, &out.AllowDeschedulerEviction
+		*out = new(bool)
+		**out = **in
+	}
+	if in.BootstrapFailureRecovery != nil {
+		in, out := &in.BootstrapFailureRecovery, &out.BootstrapFailureRecovery
+		*out = new(BootstrapFailureRecovery)
+		**out = **in
+	}
+	if in.DiskBlockSizes != nil {
+		in, out := &in.DiskBlockSizes, &out.DiskBlockSizes
+		*out = make([]DiskBlockSize, len(*in))
+		copy(*out, *in)
+	}
+	if in.DiskIOModes != nil {
+		in, out := &in.DiskIOModes, &out.DiskIOModes
+		*out = make([]DiskIOMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.IOThreads != nil {
+		in, out := &in.IOThreads, &out.IOThreads
+		*out = new(IOThreads)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoattachSerialConsole != nil {
+		in, out := &in.AutoattachSerialConsole, &out.AutoattachSerialConsole
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoattachGraphicsDevice != nil {
+		in, out := &in.AutoattachGraphicsDevice, &out.AutoattachGraphicsDevice
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoattachMemBalloon != nil {
+		in, out := &in.AutoattachMemBalloon, &out.AutoattachMemBalloon
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubevirtMachineSpec.
@@ -270,6 +498,31 @@ func (in *KubevirtMachineStatus) DeepCopyInto(out *KubevirtMachineStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InfraResourceNamePrefix != nil {
+		in, out := &in.InfraResourceNamePrefix, &out.InfraResourceNamePrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.EffectiveResources != nil {
+		in, out := &in.EffectiveResources, &out.EffectiveResources
+		*out = new(EffectiveResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureReason != nil {
+		in, out := &in.FailureReason, &out.FailureReason
+		*out = new(errors.MachineStatusError)
+		**out = **in
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]MachineVolume, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubevirtMachineStatus.
@@ -372,6 +625,67 @@ func (in *KubevirtMachineTemplateSpec) DeepCopy() *KubevirtMachineTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineNotificationWebhook) DeepCopyInto(out *MachineNotificationWebhook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineNotificationWebhook.
+func (in *MachineNotificationWebhook) DeepCopy() *MachineNotificationWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineNotificationWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineVolume) DeepCopyInto(out *MachineVolume) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineVolume.
+func (in *MachineVolume) DeepCopy() *MachineVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeHealthMonitor) DeepCopyInto(out *NodeHealthMonitor) {
+	*out = *in
+	out.UnhealthyTimeout = in.UnhealthyTimeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHealthMonitor.
+func (in *NodeHealthMonitor) DeepCopy() *NodeHealthMonitor {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeHealthMonitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeInstanceType) DeepCopyInto(out *NodeInstanceType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeInstanceType.
+func (in *NodeInstanceType) DeepCopy() *NodeInstanceType {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeInstanceType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHKeys) DeepCopyInto(out *SSHKeys) {
 	*out = *in
@@ -412,6 +726,51 @@ func (in *ServiceSpecTemplate) DeepCopy() *ServiceSpecTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustBundleSource) DeepCopyInto(out *TrustBundleSource) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustBundleSource.
+func (in *TrustBundleSource) DeepCopy() *TrustBundleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustBundleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtiofsFilesystem) DeepCopyInto(out *VirtiofsFilesystem) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtiofsFilesystem.
+func (in *VirtiofsFilesystem) DeepCopy() *VirtiofsFilesystem {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtiofsFilesystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMachineTemplateSpec) DeepCopyInto(out *VirtualMachineTemplateSpec) {
 	*out = *in
@@ -177,7 +177,7 @@ func (r *KubevirtClusterReconciler) reconcileNormal(ctx *context.ClusterContext,
 
 	// Generate ssh keys for cluster nodes, and persist them to a secret
 	clusterNodeSSHKeys := ssh.NewClusterNodeSshKeys(ctx, r.Client)
-	if !clusterNodeSSHKeys.IsPersistedToSecret() {
+	if !ctx.KubevirtCluster.Spec.DisableSSHKeyInjection && !clusterNodeSSHKeys.IsPersistedToSecret() {
 		if err := clusterNodeSSHKeys.GenerateNewKeys(); err != nil {
 			return ctrl.Result{}, errors.Wrap(err, "failed to generate new ssh keys")
 		}
@@ -209,6 +209,10 @@ func (r *KubevirtClusterReconciler) reconcileDelete(ctx *context.ClusterContext,
 		ctx.Logger.Error(err, "Failed to delete load balancer service.")
 	}
 
+	if err := r.releaseInfraCluster(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Set the LoadBalancerAvailableCondition reporting delete is started, and issue a patch in order to make
 	// this visible to the users.
 	patchHelper, err := patch.NewHelper(ctx.KubevirtCluster, r.Client)
@@ -226,6 +230,33 @@ func (r *KubevirtClusterReconciler) reconcileDelete(ctx *context.ClusterContext,
 	return ctrl.Result{}, nil
 }
 
+// releaseInfraCluster releases the infra cluster of the deleted KubevirtCluster, unless another KubevirtCluster
+// still uses the same kubeconfig secret.
+func (r *KubevirtClusterReconciler) releaseInfraCluster(ctx *context.ClusterContext) error {
+	secretRef := ctx.KubevirtCluster.Spec.InfraClusterSecretRef
+	if secretRef == nil {
+		return nil
+	}
+
+	kubevirtClusters := &infrav1.KubevirtClusterList{}
+	if err := r.Client.List(ctx, kubevirtClusters); err != nil {
+		return errors.Wrap(err, "failed to list KubevirtClusters")
+	}
+	for _, kubevirtCluster := range kubevirtClusters.Items {
+		if (kubevirtCluster.Namespace == ctx.KubevirtCluster.Namespace && kubevirtCluster.Name == ctx.KubevirtCluster.Name) ||
+			!kubevirtCluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		ref := kubevirtCluster.Spec.InfraClusterSecretRef
+		if ref != nil && ref.Namespace == secretRef.Namespace && ref.Name == secretRef.Name {
+			return nil
+		}
+	}
+
+	r.InfraCluster.ReleaseInfraCluster(secretRef)
+	return nil
+}
+
 // SetupWithManager will add watches for this controller.
 func (r *KubevirtClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	c, err := ctrl.NewControllerManagedBy(mgr).
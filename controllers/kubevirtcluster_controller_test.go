@@ -170,6 +170,46 @@ var _ = Describe("Reconcile", func() {
 			//test-kubevirt-cluster not found.
 			Expect(err).Should(HaveOccurred())
 		})
+
+		It("should release the infra cluster when no other cluster uses it", func() {
+			secretRef := &corev1.ObjectReference{Namespace: "infra", Name: "infra-kubeconfig"}
+			kubevirtCluster.Spec.InfraClusterSecretRef = secretRef
+			objects := []client.Object{
+				cluster,
+				kubevirtCluster,
+			}
+			setupClient(objects)
+			infraClusterMock.EXPECT().GenerateInfraClusterClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeClient, kubevirtCluster.Namespace, nil)
+			infraClusterMock.EXPECT().ReleaseInfraCluster(secretRef)
+
+			_, _ = kubevirtClusterReconciler.Reconcile(fakeContext, Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: kubevirtCluster.Namespace,
+					Name:      kubevirtCluster.Name,
+				},
+			})
+		})
+
+		It("should not release the infra cluster still used by another cluster", func() {
+			secretRef := &corev1.ObjectReference{Namespace: "infra", Name: "infra-kubeconfig"}
+			kubevirtCluster.Spec.InfraClusterSecretRef = secretRef
+			otherKubevirtCluster := testing.NewKubevirtCluster("other-cluster", "other-kubevirt-cluster")
+			otherKubevirtCluster.Spec.InfraClusterSecretRef = secretRef.DeepCopy()
+			objects := []client.Object{
+				cluster,
+				kubevirtCluster,
+				otherKubevirtCluster,
+			}
+			setupClient(objects)
+			infraClusterMock.EXPECT().GenerateInfraClusterClient(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeClient, kubevirtCluster.Namespace, nil)
+
+			_, _ = kubevirtClusterReconciler.Reconcile(fakeContext, Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: kubevirtCluster.Namespace,
+					Name:      kubevirtCluster.Name,
+				},
+			})
+		})
 	})
 })
 
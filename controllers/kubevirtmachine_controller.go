@@ -18,18 +18,25 @@ package controllers
 
 import (
 	gocontext "context"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
-	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	flavorv1alpha1 "kubevirt.io/api/flavor/v1alpha1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -47,16 +54,39 @@ import (
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/infracluster"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/kubevirt"
 	kubevirthandler "sigs.k8s.io/cluster-api-provider-kubevirt/pkg/kubevirt"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/notification"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/semaphore"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/ssh"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/userdata"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/workloadcluster"
 )
 
+// throttledRequeueAfter is the minimal requeue interval of machines whose infra cluster client is throttled.
+const throttledRequeueAfter = time.Minute
+
+// nodeHealthCheckInterval is the interval the workload cluster node of machines with a node health monitor is polled.
+const nodeHealthCheckInterval = 30 * time.Second
+
+// bootstrapDataHashAnnotation records on the user data secret of a VM the hash of the bootstrap data it was generated
+// from.
+const bootstrapDataHashAnnotation = "infrastructure.cluster.x-k8s.io/bootstrap-data-hash"
+
 // KubevirtMachineReconciler reconciles a KubevirtMachine object.
 type KubevirtMachineReconciler struct {
 	client.Client
 	InfraCluster    infracluster.InfraCluster
 	WorkloadCluster workloadcluster.WorkloadCluster
 	MachineFactory  kubevirt.MachineFactory
+	Recorder        record.EventRecorder
+	Notifier        notification.Notifier
+	// VMCreations limits the number of VMs provisioned at once, from their creation until they are ready.
+	VMCreations *semaphore.Semaphore
+	// VMProvisioningTimeout is the time a VM may hold its VMCreations permit without becoming ready, zero meaning
+	// no timeout.
+	VMProvisioningTimeout time.Duration
+
+	vmCreationsLock     sync.Mutex
+	vmCreationsRestored bool
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=kubevirtmachines,verbs=get;list;watch;create;update;patch;delete
@@ -64,7 +94,15 @@ type KubevirtMachineReconciler struct {
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachines;,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachineinstances;,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachineinstances;,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=cdi.kubevirt.io,resources=storageprofiles;datavolumes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=flavor.kubevirt.io,resources=virtualmachineflavors;virtualmachineclusterflavors,verbs=get;list;watch
 
 // Reconcile handles KubevirtMachine events.
 func (r *KubevirtMachineReconciler) Reconcile(goctx gocontext.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
@@ -91,6 +129,10 @@ func (r *KubevirtMachineReconciler) Reconcile(goctx gocontext.Context, req ctrl.
 
 	log = log.WithValues("machine", machine.Name)
 
+	if err := r.restoreVMCreations(goctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Handle deleted machines
 	if !kubevirtMachine.ObjectMeta.DeletionTimestamp.IsZero() {
 		// Create the machine context for this request.
@@ -103,6 +145,15 @@ func (r *KubevirtMachineReconciler) Reconcile(goctx gocontext.Context, req ctrl.
 			KubevirtMachine: kubevirtMachine,
 			Logger:          ctrl.LoggerFrom(goctx).WithName(req.Namespace).WithName(req.Name),
 		}
+		// The cluster objects are needed to access the workload cluster node of the machine, when they still exist.
+		if cluster, err := util.GetClusterFromMetadata(goctx, r.Client, machine.ObjectMeta); err == nil && cluster != nil && cluster.Spec.InfrastructureRef != nil {
+			kubevirtCluster := &infrav1.KubevirtCluster{}
+			kubevirtClusterName := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: cluster.Spec.InfrastructureRef.Name}
+			if err := r.Client.Get(goctx, kubevirtClusterName, kubevirtCluster); err == nil {
+				machineContext.Cluster = cluster
+				machineContext.KubevirtCluster = kubevirtCluster
+			}
+		}
 		return r.reconcileDelete(machineContext)
 	}
 
@@ -158,6 +209,10 @@ func (r *KubevirtMachineReconciler) Reconcile(goctx gocontext.Context, req ctrl.
 		}
 	}()
 
+	// Record the names of the objects of the machine in the infra cluster before any of them is created, so that
+	// they are found on deletion.
+	machineContext.RecordInfraResourceNamePrefix()
+
 	// Add finalizer first if not exist to avoid the race condition between init and delete
 	if !controllerutil.ContainsFinalizer(kubevirtMachine, infrav1.MachineFinalizer) {
 		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
@@ -178,20 +233,32 @@ func (r *KubevirtMachineReconciler) Reconcile(goctx gocontext.Context, req ctrl.
 		// Update the providerID on the Node
 		// The ProviderID on the Node and the providerID on  the KubevirtMachine are used to set the NodeRef
 		// This code is needed here as long as there is no Kubevirt cloud provider setting the providerID in the node
-		return r.updateNodeProviderID(machineContext)
+		if res, err := r.updateNodeProviderID(machineContext); err != nil || !res.IsZero() {
+			return res, err
+		}
+		if res, err := r.reconcileNodeLabels(machineContext); err != nil || !res.IsZero() {
+			return res, err
+		}
+		return r.reconcileNodeHealth(machineContext)
 	}
 
 	return res, err
 }
 
 func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext) (res ctrl.Result, retErr error) {
+	wasReady, wasFailed := ctx.KubevirtMachine.Status.Ready, isProvisioningFailed(ctx.KubevirtMachine)
+	defer func() {
+		r.notifyMachineTransition(ctx, wasReady, wasFailed)
+		// A VM which can't be provisioned without a user intervention must not hold back the creation of others.
+		if isProvisioningFailed(ctx.KubevirtMachine) {
+			r.VMCreations.Release(vmCreationKey(ctx.KubevirtMachine))
+		}
+	}()
 
 	// Make sure bootstrap data is available and populated.
 	if ctx.Machine.Spec.Bootstrap.DataSecretName == nil {
 		if !util.IsControlPlaneMachine(ctx.Machine) && !conditions.IsTrue(ctx.Cluster, clusterv1.ControlPlaneInitializedCondition) {
-			ctx.Logger.Info("Waiting for the control plane to be initialized...")
-			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.WaitingForControlPlaneAvailableReason, clusterv1.ConditionSeverityInfo, "")
-			return ctrl.Result{}, nil
+			return r.waitForControlPlaneInitialization(ctx), nil
 		}
 
 		ctx.Logger.Info("Waiting for Machine.Spec.Bootstrap.DataSecretName...")
@@ -202,7 +269,7 @@ func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext)
 	// Fetch SSH keys to be used for cluster nodes, and update bootstrap script cloud-init with public key
 	var clusterNodeSshKeys *ssh.ClusterNodeSshKeys
 
-	if !annotations.IsExternallyManaged(ctx.KubevirtCluster) {
+	if !annotations.IsExternallyManaged(ctx.KubevirtCluster) && !ctx.KubevirtCluster.Spec.DisableSSHKeyInjection {
 		clusterNodeSshKeys = ssh.NewClusterNodeSshKeys(ctx.ClusterContext(), r.Client)
 		if persisted := clusterNodeSshKeys.IsPersistedToSecret(); !persisted {
 			ctx.Logger.Info("Waiting for ssh keys data secret to be created by KubevirtCluster controller...")
@@ -239,6 +306,33 @@ func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext)
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
+	if infracluster.IsThrottled(infraClusterClient) {
+		ctx.Logger.Info("Requests to the infra cluster are throttled by the client-side rate limiter")
+		conditions.Set(ctx.KubevirtMachine, &clusterv1.Condition{
+			Type:    infrav1.ThrottledByInfraAPICondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  infrav1.ClientSideRateLimitedReason,
+			Message: "Requests to the infra cluster are delayed by the client-side rate limiter",
+		})
+		// back off, requeuing early only adds requests to the throttled client
+		defer func() {
+			if res.RequeueAfter > 0 && res.RequeueAfter < throttledRequeueAfter {
+				res.RequeueAfter = throttledRequeueAfter
+			}
+		}()
+	} else {
+		conditions.Delete(ctx.KubevirtMachine, infrav1.ThrottledByInfraAPICondition)
+	}
+
+	if terminating, err := isInfraNamespaceTerminating(ctx, infraClusterClient, vmNamespace); err != nil {
+		return ctrl.Result{}, err
+	} else if terminating {
+		ctx.Logger.Info(fmt.Sprintf("Infra namespace %s is terminating, VM can't be created", vmNamespace))
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.InfraNamespaceTerminatingReason, clusterv1.ConditionSeverityWarning, "Infra namespace %s is terminating", vmNamespace)
+		ctx.KubevirtMachine.Status.Ready = false
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
 	if err := r.reconcileKubevirtBootstrapSecret(ctx, infraClusterClient, vmNamespace, clusterNodeSshKeys); err != nil {
 		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo, "")
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to fetch kubevirt bootstrap secret")
@@ -253,20 +347,85 @@ func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext)
 	// Provision the underlying VM if not existing
 	if !externalMachine.Exists() {
 		ctx.KubevirtMachine.Status.Ready = false
+		// The VM is (re)created, e.g. by remediation. Its node may still exist in the workload cluster and
+		// rejoin with the same identity, so make sure the node gets reconciled again once the VM is running.
+		ctx.KubevirtMachine.Status.NodeUpdated = false
+		// The bootstrap failure recovery may reboot the new VM again.
+		ctx.KubevirtMachine.Status.BootstrapFailedChecks = 0
+		ctx.KubevirtMachine.Status.BootstrapRebooted = false
+		ctx.KubevirtMachine.Status.StartupProbeSucceeded = false
+		// KubevirtMachines are not validated by a webhook, so an invalid spec copied from a template created before
+		// the validation existed is reported instead of being retried.
+		if err := ctx.KubevirtMachine.Spec.ValidateNetworkInterfaceMultiqueue(); err != nil {
+			ctx.Logger.Info(fmt.Sprintf("VM can't be created: %v", err))
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.NetworkInterfaceMultiqueueUnsupportedReason, clusterv1.ConditionSeverityError,
+				"%v", err)
+			return ctrl.Result{}, nil
+		}
+		if found, err := r.priorityClassExists(ctx, infraClusterClient); err != nil {
+			return ctrl.Result{}, err
+		} else if !found {
+			return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+		}
+		if found, err := r.flavorExists(ctx, infraClusterClient, vmNamespace); err != nil {
+			return ctrl.Result{}, err
+		} else if !found {
+			return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+		}
+		if !r.VMCreations.TryAcquire(vmCreationKey(ctx.KubevirtMachine)) {
+			ctx.Logger.Info("Waiting for other VMs to be provisioned...")
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForGlobalCapacityReason, clusterv1.ConditionSeverityInfo,
+				"At most %d VMs are provisioned at once", r.VMCreations.Capacity())
+			return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+		}
+		for _, disk := range kubevirt.DiskCacheModeOverrides(ctx) {
+			r.Recorder.Eventf(ctx.KubevirtMachine, corev1.EventTypeWarning, "DiskCacheModeOverridden",
+				"Cache mode %s of disk %s is overridden with the cache mode %s enforced by the KubevirtCluster", disk.Cache, disk.Name, ctx.KubevirtCluster.Spec.DiskCacheMode)
+		}
 		if err := externalMachine.Create(ctx.Context); err != nil {
+			r.VMCreations.Release(vmCreationKey(ctx.KubevirtMachine))
 			return ctrl.Result{}, errors.Wrap(err, "failed to create VM instance")
 		}
 		ctx.Logger.Info("VM Created, waiting on vm to be provisioned.")
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.VMProvisioningReason, clusterv1.ConditionSeverityInfo, "")
 		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
 	}
 
+	if err := r.reconcileVolumes(ctx, infraClusterClient, vmNamespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Checks to see if a VM's active VMI is ready or not
 	if externalMachine.IsReady() {
+		if ctx.KubevirtCluster.Spec.GuestAgentPolicy == infrav1.GuestAgentRequired && !externalMachine.IsAgentConnected() {
+			ctx.Logger.Info("Waiting for the guest agent of the VM to connect...")
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.AgentDisconnectedReason, clusterv1.ConditionSeverityWarning, "The guest agent of the VM is not connected")
+			ctx.KubevirtMachine.Status.Ready = false
+			return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+		}
+		// The startup probe only gates the boot of the VM, it is not run anymore once it succeeded.
+		if ctx.KubevirtMachine.Spec.StartupProbe != nil && !ctx.KubevirtMachine.Status.StartupProbeSucceeded {
+			if !externalMachine.IsStarted() {
+				ctx.Logger.Info("Waiting for the startup probe of the VM to succeed...")
+				ctx.KubevirtMachine.Status.Ready = false
+				r.releaseTimedOutVMCreation(ctx)
+				conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForStartupProbeReason, clusterv1.ConditionSeverityInfo,
+					"Waiting for the startup probe of the VM to succeed")
+				return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+			}
+			ctx.KubevirtMachine.Status.StartupProbeSucceeded = true
+		}
 		// Mark VMProvisionedCondition to indicate that the VM has successfully started
 		conditions.MarkTrue(ctx.KubevirtMachine, infrav1.VMProvisionedCondition)
+		r.VMCreations.Release(vmCreationKey(ctx.KubevirtMachine))
 	} else {
 		// Waiting for VM to boot
 		ctx.KubevirtMachine.Status.Ready = false
+		r.releaseTimedOutVMCreation(ctx)
+		if ctx.KubevirtMachine.Spec.StartupProbe != nil && !ctx.KubevirtMachine.Status.StartupProbeSucceeded {
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForStartupProbeReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for the startup probe of the VM to succeed")
+		}
 		ctx.Logger.Info("KubeVirt VM is not fully provisioned and running...")
 		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
 	}
@@ -280,13 +439,22 @@ func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext)
 
 	if externalMachine.SupportsCheckingIsBootstrapped() && !conditions.IsTrue(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition) {
 		if !externalMachine.IsBootstrapped() {
-			ctx.Logger.Info("Waiting for underlying VM to bootstrap...")
-			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapFailedReason, clusterv1.ConditionSeverityWarning, "VM not bootstrapped yet")
 			ctx.KubevirtMachine.Status.Ready = false
+			if rotated, err := r.isBootstrapDataRotated(ctx); err != nil {
+				return ctrl.Result{}, err
+			} else if rotated {
+				return r.recreateWithRotatedBootstrapData(ctx, infraClusterClient, vmNamespace, externalMachine)
+			}
+			ctx.Logger.Info("Waiting for underlying VM to bootstrap...")
+			if ctx.KubevirtMachine.Spec.BootstrapFailureRecovery != nil {
+				return r.recoverBootstrapFailure(ctx, externalMachine)
+			}
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapFailedReason, clusterv1.ConditionSeverityWarning, "%s", bootstrapProgressMessage(ctx, externalMachine))
 			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 		}
 		// Update the condition BootstrapExecSucceededCondition
 		conditions.MarkTrue(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)
+		ctx.KubevirtMachine.Status.BootstrapFailedChecks = 0
 		ctx.Logger.Info("Underlying VM has boostrapped.")
 	}
 
@@ -327,9 +495,336 @@ func (r *KubevirtMachineReconciler) reconcileNormal(ctx *context.MachineContext)
 		ctx.KubevirtMachine.Status.Ready = false
 	}
 
+	if err := r.reconcileEffectiveResources(ctx, infraClusterClient, vmNamespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// reconcileEffectiveResources reports the resources of the running VMI, read from its virt-launcher pod, which
+// reflect the memory and CPU overcommit applied by KubeVirt.
+func (r *KubevirtMachineReconciler) reconcileEffectiveResources(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) error {
+	vmi := &kubevirtv1.VirtualMachineInstance{}
+	if err := infraClusterClient.Get(ctx, client.ObjectKey{Namespace: vmNamespace, Name: ctx.VMName()}, vmi); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to get VMI")
+	}
+
+	pods := &corev1.PodList{}
+	if err := infraClusterClient.List(ctx, pods, client.InNamespace(vmNamespace), client.MatchingLabels{kubevirtv1.CreatedByLabel: string(vmi.UID)}); err != nil {
+		return errors.Wrap(err, "failed to list the virt-launcher pods of the VMI")
+	}
+	// while the VMI migrates, its source pod runs on the node of the VMI
+	var launcherPod *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if launcherPod == nil || pod.Spec.NodeName == vmi.Status.NodeName {
+			launcherPod = pod
+		}
+	}
+
+	ctx.KubevirtMachine.Status.EffectiveResources = kubevirt.EffectiveResources(vmi, launcherPod)
+	return nil
+}
+
+// reconcileVolumes reports the DataVolumes created for the VM from its DataVolumeTemplates, and their claims.
+func (r *KubevirtMachineReconciler) reconcileVolumes(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) error {
+	vm := &kubevirtv1.VirtualMachine{}
+	if err := infraClusterClient.Get(ctx, client.ObjectKey{Namespace: vmNamespace, Name: ctx.VMName()}, vm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to get VM")
+	}
+
+	var volumes []infrav1.MachineVolume
+	for _, dataVolumeTemplate := range vm.Spec.DataVolumeTemplates {
+		volume := infrav1.MachineVolume{Name: dataVolumeTemplate.Name}
+		key := client.ObjectKey{Namespace: vmNamespace, Name: dataVolumeTemplate.Name}
+
+		dataVolume := &cdiv1.DataVolume{}
+		if err := infraClusterClient.Get(ctx, key, dataVolume); err == nil {
+			volume.DataVolumePhase = dataVolume.Status.Phase
+		} else if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get DataVolume %s", dataVolumeTemplate.Name)
+		}
+
+		claim := &corev1.PersistentVolumeClaim{}
+		if err := infraClusterClient.Get(ctx, key, claim); err == nil {
+			volume.ClaimPhase = claim.Status.Phase
+		} else if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get PersistentVolumeClaim %s", dataVolumeTemplate.Name)
+		}
+
+		volumes = append(volumes, volume)
+	}
+
+	ctx.KubevirtMachine.Status.Volumes = volumes
+	return nil
+}
+
+// vmCreationKey returns the key identifying the machine among the VMs provisioned at once by the controller.
+func vmCreationKey(kubevirtMachine *infrav1.KubevirtMachine) string {
+	return kubevirtMachine.Namespace + "/" + kubevirtMachine.Name
+}
+
+// releaseTimedOutVMCreation releases the VMCreations permit of a VM which is still not ready after the provisioning
+// timeout, letting other VMs be provisioned.
+func (r *KubevirtMachineReconciler) releaseTimedOutVMCreation(ctx *context.MachineContext) {
+	since, held := r.VMCreations.HeldSince(vmCreationKey(ctx.KubevirtMachine))
+	if held && r.VMProvisioningTimeout > 0 && time.Since(since) > r.VMProvisioningTimeout {
+		ctx.Logger.Info(fmt.Sprintf("VM is not ready after %s, letting other VMs be provisioned", r.VMProvisioningTimeout))
+		r.VMCreations.Release(vmCreationKey(ctx.KubevirtMachine))
+	}
+}
+
+// restoreVMCreations acquires the VMCreations permits of the VMs which were created and not ready yet when the
+// controller restarted, so that they keep counting against the limit. Their provisioning timeout starts again.
+func (r *KubevirtMachineReconciler) restoreVMCreations(ctx gocontext.Context) error {
+	r.vmCreationsLock.Lock()
+	defer r.vmCreationsLock.Unlock()
+
+	if r.vmCreationsRestored || r.VMCreations.Capacity() == 0 {
+		return nil
+	}
+
+	kubevirtMachines := &infrav1.KubevirtMachineList{}
+	if err := r.Client.List(ctx, kubevirtMachines); err != nil {
+		return errors.Wrap(err, "failed to list KubevirtMachines to restore the VMs being provisioned")
+	}
+	for i := range kubevirtMachines.Items {
+		kubevirtMachine := &kubevirtMachines.Items[i]
+		if !kubevirtMachine.DeletionTimestamp.IsZero() || !conditions.IsFalse(kubevirtMachine, infrav1.VMProvisionedCondition) {
+			continue
+		}
+		switch conditions.GetReason(kubevirtMachine, infrav1.VMProvisionedCondition) {
+		case infrav1.VMProvisioningReason, infrav1.WaitingForStartupProbeReason:
+			r.VMCreations.Acquire(vmCreationKey(kubevirtMachine))
+		}
+	}
+	r.vmCreationsRestored = true
+	return nil
+}
+
+// isProvisioningFailed checks if the VM of the machine can't be provisioned without a user intervention.
+func isProvisioningFailed(kubevirtMachine *infrav1.KubevirtMachine) bool {
+	condition := conditions.Get(kubevirtMachine, infrav1.VMProvisionedCondition)
+	return condition != nil && condition.Status == corev1.ConditionFalse && condition.Severity == clusterv1.ConditionSeverityError
+}
+
+// notifyMachineTransition notifies the machine notification webhook of the cluster when the machine became ready,
+// or when its provisioning failed.
+func (r *KubevirtMachineReconciler) notifyMachineTransition(ctx *context.MachineContext, wasReady, wasFailed bool) {
+	webhook := ctx.KubevirtCluster.Spec.MachineNotificationWebhook
+	if r.Notifier == nil || webhook == nil {
+		return
+	}
+
+	event := notification.MachineEvent{
+		Namespace: ctx.KubevirtMachine.Namespace,
+		Name:      ctx.KubevirtMachine.Name,
+		Addresses: ctx.KubevirtMachine.Status.Addresses,
+	}
+	if ctx.KubevirtMachine.Spec.ProviderID != nil {
+		event.ProviderID = *ctx.KubevirtMachine.Spec.ProviderID
+	}
+
+	switch {
+	case !wasReady && ctx.KubevirtMachine.Status.Ready:
+		event.Event = notification.MachineReadyEvent
+	case !wasFailed && isProvisioningFailed(ctx.KubevirtMachine):
+		event.Event = notification.MachineFailedEvent
+		event.Message = conditions.GetMessage(ctx.KubevirtMachine, infrav1.VMProvisionedCondition)
+	default:
+		return
+	}
+
+	ctx.Logger.Info(fmt.Sprintf("Notifying the machine notification webhook, machine is %s", event.Event))
+	r.Notifier.Notify(webhook.URL, event)
+}
+
+// recoverBootstrapFailure counts the failed bootstrap checks of the VM, and reboots the VM once they reach the
+// threshold of the bootstrap failure recovery. When the bootstrap keeps failing after the reboot, the failure is
+// escalated to an error.
+func (r *KubevirtMachineReconciler) recoverBootstrapFailure(ctx *context.MachineContext, externalMachine kubevirt.MachineInterface) (ctrl.Result, error) {
+	status := &ctx.KubevirtMachine.Status
+	status.BootstrapFailedChecks++
+	threshold := ctx.KubevirtMachine.Spec.BootstrapFailureRecovery.RebootAfterFailedChecks
+
+	switch {
+	case status.BootstrapFailedChecks < threshold:
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapFailedReason, clusterv1.ConditionSeverityWarning, "%s", bootstrapProgressMessage(ctx, externalMachine))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	case !status.BootstrapRebooted:
+		ctx.Logger.Info(fmt.Sprintf("Rebooting the VM after %d failed bootstrap checks", status.BootstrapFailedChecks))
+		if err := externalMachine.Reboot(); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to reboot the VM")
+		}
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapRebootedReason, clusterv1.ConditionSeverityInfo,
+			"The VM was rebooted after %d failed bootstrap checks", status.BootstrapFailedChecks)
+		status.BootstrapRebooted = true
+		status.StartupProbeSucceeded = false
+		status.BootstrapFailedChecks = 0
+		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
+	default:
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapFailedReason, clusterv1.ConditionSeverityError,
+			"The VM failed to bootstrap after a reboot: %s", bootstrapProgressMessage(ctx, externalMachine))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+}
+
+// isBootstrapDataRotated checks if the bootstrap data secret of the machine changed since the user data of the VM was
+// generated, e.g. with a fresh join token.
+func (r *KubevirtMachineReconciler) isBootstrapDataRotated(ctx *context.MachineContext) (bool, error) {
+	dataHash, ok := ctx.BootstrapDataSecret.Annotations[bootstrapDataHashAnnotation]
+	if !ok {
+		// the user data was generated before its bootstrap data got tracked
+		return false, nil
+	}
+
+	s := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: ctx.Machine.GetNamespace(), Name: *ctx.Machine.Spec.Bootstrap.DataSecretName}
+	if err := r.Client.Get(ctx, key, s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to retrieve bootstrap data secret for KubevirtMachine %s/%s", ctx.Machine.GetNamespace(), ctx.Machine.GetName())
+	}
+	return bootstrapDataHash(s.Data["value"]) != dataHash, nil
+}
+
+// recreateWithRotatedBootstrapData deletes the VM whose bootstrap data got rotated before the VM bootstrapped, as
+// the bootstrap would never succeed, e.g. with an expired join token. The user data is deleted too, so that the VM
+// is recreated with user data generated from the rotated bootstrap data.
+func (r *KubevirtMachineReconciler) recreateWithRotatedBootstrapData(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string, externalMachine kubevirt.MachineInterface) (ctrl.Result, error) {
+	ctx.Logger.Info("The bootstrap data was rotated before the VM bootstrapped, recreating the VM...")
+	if err := r.deleteKubevirtBootstrapSecret(ctx, infraClusterClient, vmNamespace); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to delete bootstrap secret")
+	}
+	if err := externalMachine.Delete(); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to delete VM")
+	}
+	conditions.MarkFalse(ctx.KubevirtMachine, infrav1.BootstrapExecSucceededCondition, infrav1.BootstrapDataRotatedReason, clusterv1.ConditionSeverityInfo,
+		"The bootstrap data was rotated before the VM bootstrapped, the VM is recreated")
+	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+}
+
+// bootstrapDataHash returns the hash of the bootstrap data the user data of a VM is generated from.
+func bootstrapDataHash(value []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(value))
+}
+
+// bootstrapProgressMessage reports the progress of the VM bootstrap through the bootstrap markers of the cluster.
+// The completion of the bootstrap itself is the last stage.
+func bootstrapProgressMessage(ctx *context.MachineContext, externalMachine kubevirt.MachineInterface) string {
+	markers := ctx.KubevirtCluster.Spec.BootstrapMarkers
+	if len(markers) == 0 {
+		return "VM not bootstrapped yet"
+	}
+
+	reached := externalMachine.BootstrapProgress()
+	stages := len(markers) + 1
+	waitingFor := "bootstrap completion"
+	if reached < len(markers) {
+		waitingFor = markers[reached].Name
+	}
+	return fmt.Sprintf("VM not bootstrapped yet, %d/%d stages completed (%d%%), waiting for %s", reached, stages, reached*100/stages, waitingFor)
+}
+
+// priorityClassExists checks that the PriorityClass used by the machine's VM exists in the infra cluster,
+// marking the VMProvisionedCondition when it is missing.
+func (r *KubevirtMachineReconciler) priorityClassExists(ctx *context.MachineContext, infraClusterClient client.Client) (bool, error) {
+	priorityClassName := kubevirt.PriorityClassName(ctx)
+	if priorityClassName == "" {
+		return true, nil
+	}
+
+	priorityClass := &schedulingv1.PriorityClass{}
+	if err := infraClusterClient.Get(ctx, client.ObjectKey{Name: priorityClassName}, priorityClass); err != nil {
+		switch {
+		case apierrors.IsNotFound(err):
+			ctx.Logger.Info(fmt.Sprintf("PriorityClass %s does not exist in the infra cluster", priorityClassName))
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.PriorityClassNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"PriorityClass %s does not exist in the infra cluster", priorityClassName)
+			return false, nil
+		case apierrors.IsForbidden(err):
+			// infra cluster credentials may not allow reading cluster scoped objects,
+			// let the infra cluster reject the VM if the priority class is missing.
+			return true, nil
+		default:
+			return false, errors.Wrapf(err, "failed to fetch PriorityClass %s", priorityClassName)
+		}
+	}
+
+	return true, nil
+}
+
+// flavorExists checks that the flavor referenced by the machine's VM exists in the infra cluster, marking the
+// VMProvisionedCondition otherwise. Infra cluster credentials which can't read the flavor are reported as an error,
+// as the flavor may well exist. The pinned KubeVirt API has flavors only, so there is no preference to look up and
+// no revision to pin: KubeVirt applies the current flavor when the VM starts.
+func (r *KubevirtMachineReconciler) flavorExists(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) (bool, error) {
+	flavorMatcher := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor
+	if flavorMatcher == nil {
+		return true, nil
+	}
+
+	var flavor client.Object
+	var flavorKey client.ObjectKey
+	switch flavorMatcher.Kind {
+	case "", "VirtualMachineClusterFlavor":
+		flavor = &flavorv1alpha1.VirtualMachineClusterFlavor{}
+		flavorKey = client.ObjectKey{Name: flavorMatcher.Name}
+	case "VirtualMachineFlavor":
+		flavor = &flavorv1alpha1.VirtualMachineFlavor{}
+		flavorKey = client.ObjectKey{Namespace: vmNamespace, Name: flavorMatcher.Name}
+	default:
+		// let the infra cluster reject the unknown flavor kind
+		return true, nil
+	}
+
+	if err := infraClusterClient.Get(ctx, flavorKey, flavor); err != nil {
+		if apierrors.IsNotFound(err) {
+			ctx.Logger.Info(fmt.Sprintf("Flavor %s does not exist in the infra cluster", flavorMatcher.Name))
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.FlavorNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"Flavor %s does not exist in the infra cluster", flavorMatcher.Name)
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to fetch flavor %s", flavorMatcher.Name)
+	}
+
+	return true, nil
+}
+
+// waitForControlPlaneInitialization marks a worker machine as waiting for the control plane to be initialized,
+// escalating the condition once the cluster's control plane initialization timeout has expired.
+func (r *KubevirtMachineReconciler) waitForControlPlaneInitialization(ctx *context.MachineContext) ctrl.Result {
+	timeout := ctx.KubevirtCluster.Spec.ControlPlaneInitializationTimeout
+	if timeout == nil {
+		ctx.Logger.Info("Waiting for the control plane to be initialized...")
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.WaitingForControlPlaneAvailableReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{}
+	}
+
+	waiting := time.Since(ctx.KubevirtMachine.CreationTimestamp.Time)
+	if waiting < timeout.Duration {
+		ctx.Logger.Info("Waiting for the control plane to be initialized...")
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.WaitingForControlPlaneAvailableReason, clusterv1.ConditionSeverityInfo, "Control plane is still initializing")
+		return ctrl.Result{RequeueAfter: timeout.Duration - waiting}
+	}
+
+	ctx.Logger.Info(fmt.Sprintf("Control plane is not initialized after %s, it appears to be stuck", timeout.Duration))
+	conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.ControlPlaneInitializationStuckReason, clusterv1.ConditionSeverityWarning,
+		"Control plane is not initialized after %s", timeout.Duration)
+	return ctrl.Result{}
+}
+
 func (r *KubevirtMachineReconciler) updateNodeProviderID(ctx *context.MachineContext) (ctrl.Result, error) {
 	// If the provider ID is already updated on the Node, return
 	if ctx.KubevirtMachine.Status.NodeUpdated {
@@ -346,9 +841,8 @@ func (r *KubevirtMachineReconciler) updateNodeProviderID(ctx *context.MachineCon
 	}
 
 	// using workload cluster client, get the corresponding cluster node
-	workloadClusterNode := &corev1.Node{}
-	workloadClusterNodeKey := client.ObjectKey{Namespace: ctx.KubevirtMachine.Namespace, Name: ctx.KubevirtMachine.Name}
-	if err := workloadClusterClient.Get(ctx, workloadClusterNodeKey, workloadClusterNode); err != nil {
+	workloadClusterNode, err := getWorkloadClusterNode(ctx, workloadClusterClient)
+	if err != nil {
 		if apierrors.IsNotFound(err) {
 			ctx.Logger.Info(fmt.Sprintf("Waiting for workload cluster node to appear for machine %s/%s...", ctx.KubevirtMachine.Namespace, ctx.KubevirtMachine.Name))
 			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
@@ -357,8 +851,17 @@ func (r *KubevirtMachineReconciler) updateNodeProviderID(ctx *context.MachineCon
 		}
 	}
 
-	if workloadClusterNode.Spec.ProviderID == *ctx.KubevirtMachine.Spec.ProviderID {
+	// The providerID is derived from the machine name, so a node rejoining after its VM got recreated
+	// already carries the providerID of this machine. The providerID of a node can't be changed once set,
+	// so a node carrying another providerID does not belong to this machine.
+	providerID := *ctx.KubevirtMachine.Spec.ProviderID
+	if workloadClusterNode.Spec.ProviderID != "" && workloadClusterNode.Spec.ProviderID != providerID {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Errorf("workload cluster node %s has provider id %s, expected %s", workloadClusterNode.Name, workloadClusterNode.Spec.ProviderID, providerID)
+	}
+
+	if workloadClusterNode.Spec.ProviderID == providerID {
 		// Node is already updated, return
+		ctx.KubevirtMachine.Status.NodeUpdated = true
 		return ctrl.Result{}, nil
 	}
 
@@ -367,8 +870,8 @@ func (r *KubevirtMachineReconciler) updateNodeProviderID(ctx *context.MachineCon
 	ctx.Logger.Info("Patching node with provider id...")
 
 	// using workload cluster client, patch cluster node
-	patchStr := fmt.Sprintf(`{"spec": {"providerID": "%s"}}`, *ctx.KubevirtMachine.Spec.ProviderID)
-	mergePatch := client.RawPatch(types.MergePatchType, []byte(patchStr))
+	mergePatch := client.MergeFrom(workloadClusterNode.DeepCopy())
+	workloadClusterNode.Spec.ProviderID = providerID
 	if err := workloadClusterClient.Patch(gocontext.TODO(), workloadClusterNode, mergePatch); err != nil {
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrapf(err, "failed to patch workload cluster node")
 	}
@@ -377,13 +880,352 @@ func (r *KubevirtMachineReconciler) updateNodeProviderID(ctx *context.MachineCon
 	return ctrl.Result{}, nil
 }
 
-func (r *KubevirtMachineReconciler) reconcileDelete(ctx *context.MachineContext) (ctrl.Result, error) {
+// reconcileNodeLabels sets the labels managed by the controller on the workload cluster node of the machine. Unlike
+// the providerID, the labels are checked on every reconcile, so that labels removed from the node or changed on the
+// KubevirtCluster or the KubevirtMachine are applied again.
+func (r *KubevirtMachineReconciler) reconcileNodeLabels(ctx *context.MachineContext) (ctrl.Result, error) {
+	nodeLabels := desiredNodeLabels(ctx)
+	if len(nodeLabels) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	workloadClusterClient, err := r.WorkloadCluster.GenerateWorkloadClusterClient(ctx)
+	if err != nil {
+		ctx.Logger.Error(err, "Workload cluster client is not available")
+	}
+	if workloadClusterClient == nil {
+		ctx.Logger.Info("Waiting for workload cluster client...")
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	workloadClusterNode, err := getWorkloadClusterNode(ctx, workloadClusterClient)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctx.Logger.Info(fmt.Sprintf("Waiting for workload cluster node to appear for machine %s/%s...", ctx.KubevirtMachine.Namespace, ctx.KubevirtMachine.Name))
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrapf(err, "failed to fetch workload cluster node")
+	}
+
+	if nodeHasLabels(workloadClusterNode, nodeLabels) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx.Logger.Info("Patching node with labels...")
+	mergePatch := client.MergeFrom(workloadClusterNode.DeepCopy())
+	if workloadClusterNode.Labels == nil {
+		workloadClusterNode.Labels = map[string]string{}
+	}
+	for key, value := range nodeLabels {
+		workloadClusterNode.Labels[key] = value
+	}
+	if err := workloadClusterClient.Patch(gocontext.TODO(), workloadClusterNode, mergePatch); err != nil {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrapf(err, "failed to patch workload cluster node")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileNodeHealth reports the health of the workload cluster node of the machine in the NodeHealthy condition,
+// when the cluster reports node conditions or the machine has a node health monitor. The condition mirrors the
+// presence and the readiness of the node; with a node health monitor, a node not ready is only reported with a
+// Warning severity once it has not been ready for the unhealthy timeout. The node is polled, as the controller does
+// not watch the workload cluster.
+func (r *KubevirtMachineReconciler) reconcileNodeHealth(ctx *context.MachineContext) (ctrl.Result, error) {
+	monitor := ctx.KubevirtMachine.Spec.NodeHealthMonitor
+	reportConditions := ctx.KubevirtCluster != nil && ctx.KubevirtCluster.Spec.ReportNodeConditions
+	if monitor == nil && !reportConditions {
+		conditions.Delete(ctx.KubevirtMachine, infrav1.NodeHealthyCondition)
+		return ctrl.Result{}, nil
+	}
+
+	workloadClusterClient, err := r.WorkloadCluster.GenerateWorkloadClusterClient(ctx)
+	if err != nil {
+		ctx.Logger.Error(err, "Workload cluster client is not available")
+	}
+	if workloadClusterClient == nil {
+		return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, nil
+	}
+
+	node, err := getWorkloadClusterNode(ctx, workloadClusterClient)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.NodeHealthyCondition, infrav1.NodeNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"Workload cluster node of the machine not found")
+			// a missing node is remediated by the MachineHealthCheck itself
+			return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, nil
+		}
+		return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, errors.Wrapf(err, "failed to fetch workload cluster node")
+	}
+
+	ready := false
+	notReadySince := node.CreationTimestamp.Time
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status == corev1.ConditionTrue
+			notReadySince = condition.LastTransitionTime.Time
+		}
+	}
+
+	if ready {
+		conditions.MarkTrue(ctx.KubevirtMachine, infrav1.NodeHealthyCondition)
+		return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, nil
+	}
+	if monitor == nil {
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.NodeHealthyCondition, infrav1.NodeNotReadyReason, clusterv1.ConditionSeverityWarning,
+			"Workload cluster node %s is not ready", node.Name)
+		return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, nil
+	}
+
+	if remaining := monitor.UnhealthyTimeout.Duration - time.Since(notReadySince); remaining > 0 {
+		ctx.Logger.Info(fmt.Sprintf("Workload cluster node %s is not ready", node.Name))
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.NodeHealthyCondition, infrav1.NodeNotReadyReason, clusterv1.ConditionSeverityInfo,
+			"Workload cluster node %s is not ready", node.Name)
+		if remaining > nodeHealthCheckInterval {
+			remaining = nodeHealthCheckInterval
+		}
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	message := fmt.Sprintf("Workload cluster node %s has not been ready for more than %s", node.Name, monitor.UnhealthyTimeout.Duration)
+	ctx.Logger.Info(message)
+	conditions.MarkFalse(ctx.KubevirtMachine, infrav1.NodeHealthyCondition, infrav1.NodeUnhealthyReason, clusterv1.ConditionSeverityWarning, "%s", message)
+	if monitor.FailMachine && ctx.KubevirtMachine.Status.FailureReason == nil {
+		failureReason := capierrors.UpdateMachineError
+		ctx.KubevirtMachine.Status.FailureReason = &failureReason
+		ctx.KubevirtMachine.Status.FailureMessage = &message
+	}
+	return ctrl.Result{RequeueAfter: nodeHealthCheckInterval}, nil
+}
+
+// deleteWorkloadClusterNode deletes the workload cluster node patched with the providerID of the machine, once its
+// VM is gone. The volume attachments of the node are deleted first, so that CSI drivers detach the volumes of the
+// node without waiting for the node to come back. The node is left alone when the workload cluster is not reachable.
+func (r *KubevirtMachineReconciler) deleteWorkloadClusterNode(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) (ctrl.Result, error) {
+	if !ctx.KubevirtMachine.Status.NodeUpdated || ctx.KubevirtCluster == nil {
+		return ctrl.Result{}, nil
+	}
+
+	vmi := &kubevirtv1.VirtualMachineInstance{}
+	if err := infraClusterClient.Get(ctx, client.ObjectKey{Namespace: vmNamespace, Name: ctx.VMName()}, vmi); err == nil {
+		ctx.Logger.Info("Waiting for VM to shut down before deleting the workload cluster node...")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrap(err, "failed to get VMI")
+	}
+
+	workloadClusterClient, err := r.WorkloadCluster.GenerateWorkloadClusterClient(ctx)
+	if err != nil || workloadClusterClient == nil {
+		ctx.Logger.Info("Workload cluster is not available, skipping the deletion of the workload cluster node")
+		ctx.KubevirtMachine.Status.NodeUpdated = false
+		return ctrl.Result{}, nil
+	}
+
+	node, err := getWorkloadClusterNode(ctx, workloadClusterClient)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctx.KubevirtMachine.Status.NodeUpdated = false
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrap(err, "failed to fetch workload cluster node")
+	}
+	if ctx.KubevirtMachine.Spec.ProviderID == nil || node.Spec.ProviderID != *ctx.KubevirtMachine.Spec.ProviderID {
+		// the node does not belong to this machine anymore
+		ctx.KubevirtMachine.Status.NodeUpdated = false
+		return ctrl.Result{}, nil
+	}
+
+	volumeAttachments := &storagev1.VolumeAttachmentList{}
+	if err := workloadClusterClient.List(ctx, volumeAttachments); err != nil {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrap(err, "failed to list workload cluster volume attachments")
+	}
+	for i := range volumeAttachments.Items {
+		volumeAttachment := &volumeAttachments.Items[i]
+		if volumeAttachment.Spec.NodeName != node.Name || !volumeAttachment.DeletionTimestamp.IsZero() {
+			continue
+		}
+		ctx.Logger.Info(fmt.Sprintf("Deleting volume attachment %s of workload cluster node %s...", volumeAttachment.Name, node.Name))
+		if err := workloadClusterClient.Delete(ctx, volumeAttachment); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrapf(err, "failed to delete volume attachment %s", volumeAttachment.Name)
+		}
+	}
+
+	ctx.Logger.Info(fmt.Sprintf("Deleting workload cluster node %s...", node.Name))
+	if err := workloadClusterClient.Delete(ctx, node); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, errors.Wrapf(err, "failed to delete workload cluster node %s", node.Name)
+	}
+	ctx.KubevirtMachine.Status.NodeUpdated = false
+
+	return ctrl.Result{}, nil
+}
+
+// cordonWorkloadClusterNode marks the workload cluster node of the machine unschedulable, leaving the pods it runs
+// in place.
+func (r *KubevirtMachineReconciler) cordonWorkloadClusterNode(ctx *context.MachineContext) error {
+	if !ctx.KubevirtMachine.Status.NodeUpdated {
+		return nil
+	}
+
+	workloadClusterClient, err := r.WorkloadCluster.GenerateWorkloadClusterClient(ctx)
+	if err != nil || workloadClusterClient == nil {
+		ctx.Logger.Info("Workload cluster is not available, skipping the cordon of the workload cluster node")
+		return nil
+	}
+
+	node, err := getWorkloadClusterNode(ctx, workloadClusterClient)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to fetch workload cluster node")
+	}
+	if ctx.KubevirtMachine.Spec.ProviderID == nil || node.Spec.ProviderID != *ctx.KubevirtMachine.Spec.ProviderID {
+		// the node does not belong to this machine anymore
+		return nil
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+
+	ctx.Logger.Info(fmt.Sprintf("Cordoning workload cluster node %s...", node.Name))
+	mergePatch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = true
+	if err := workloadClusterClient.Patch(ctx, node, mergePatch); err != nil {
+		return errors.Wrapf(err, "failed to cordon workload cluster node %s", node.Name)
+	}
+
+	return nil
+}
+
+// getWorkloadClusterNode returns the workload cluster node of the machine, matched according to the node match
+// strategy of the cluster. A NotFound error is returned when no node matches the machine.
+func getWorkloadClusterNode(ctx *context.MachineContext, workloadClusterClient client.Client) (*corev1.Node, error) {
+	strategy := infrav1.NodeMatchByName
+	if ctx.KubevirtCluster != nil && ctx.KubevirtCluster.Spec.NodeMatchStrategy != "" {
+		strategy = ctx.KubevirtCluster.Spec.NodeMatchStrategy
+	}
+
+	if strategy == infrav1.NodeMatchByName {
+		node := &corev1.Node{}
+		if err := workloadClusterClient.Get(ctx, client.ObjectKey{Name: ctx.KubevirtMachine.Name}, node); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := workloadClusterClient.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	for i := range nodes.Items {
+		if nodeMatchesMachine(ctx, strategy, &nodes.Items[i]) {
+			return &nodes.Items[i], nil
+		}
+	}
+	return nil, apierrors.NewNotFound(corev1.Resource("nodes"), ctx.KubevirtMachine.Name)
+}
+
+// nodeMatchesMachine checks if the node registered for the machine, by the hostname or the IP address of the VM.
+func nodeMatchesMachine(ctx *context.MachineContext, strategy infrav1.NodeMatchStrategy, node *corev1.Node) bool {
+	switch strategy {
+	case infrav1.NodeMatchByHostname:
+		hostname, subdomain := kubevirt.GuestHostname(ctx)
+		nodeHostnames := []string{node.Name, node.Labels[corev1.LabelHostname]}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeHostName {
+				nodeHostnames = append(nodeHostnames, address.Address)
+			}
+		}
+		for _, nodeHostname := range nodeHostnames {
+			if nodeHostname == hostname {
+				return true
+			}
+			// the node may register with the FQDN of the VM, i.e. <hostname>.<subdomain>.<namespace>.svc...
+			if fqdn := hostname + "." + subdomain; subdomain != "" && (nodeHostname == fqdn || strings.HasPrefix(nodeHostname, fqdn+".")) {
+				return true
+			}
+		}
+	case infrav1.NodeMatchByIP:
+		for _, machineAddress := range ctx.KubevirtMachine.Status.Addresses {
+			if machineAddress.Type != clusterv1.MachineInternalIP || machineAddress.Address == "" {
+				continue
+			}
+			for _, address := range node.Status.Addresses {
+				if address.Type == corev1.NodeInternalIP && address.Address == machineAddress.Address {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// desiredNodeLabels returns the labels the controller manages on the workload cluster node of this machine. The
+// instance type label takes precedence over the default node labels of the cluster, and the node labels of the
+// machine take precedence over both.
+func desiredNodeLabels(ctx *context.MachineContext) map[string]string {
+	nodeLabels := map[string]string{}
+	if ctx.KubevirtCluster != nil {
+		for key, value := range ctx.KubevirtCluster.Spec.DefaultNodeLabels {
+			nodeLabels[key] = value
+		}
+	}
+	if instanceType := kubevirt.NodeInstanceType(ctx); instanceType != "" {
+		nodeLabels[corev1.LabelInstanceTypeStable] = instanceType
+	}
+	for key, value := range ctx.KubevirtMachine.Spec.NodeLabels {
+		nodeLabels[key] = value
+	}
+	return nodeLabels
+}
+
+// nodeHasLabels checks if all the given labels are already set on the node.
+func nodeHasLabels(node *corev1.Node, nodeLabels map[string]string) bool {
+	for key, value := range nodeLabels {
+		if current, ok := node.Labels[key]; !ok || current != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *KubevirtMachineReconciler) reconcileDelete(ctx *context.MachineContext) (_ ctrl.Result, rerr error) {
+	r.VMCreations.Release(vmCreationKey(ctx.KubevirtMachine))
 
 	patchHelper, err := patch.NewHelper(ctx.KubevirtMachine, r.Client)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Always attempt to Patch the KubevirtMachine object and status, so that the conditions reported while waiting
+	// for the deletion to complete are visible to the users.
+	defer func() {
+		if err := ctx.PatchKubevirtMachine(patchHelper); err != nil {
+			ctx.Logger.Error(err, "failed to patch KubevirtMachine")
+			if rerr == nil {
+				rerr = errors.Wrap(err, "failed to patch KubevirtMachine")
+			}
+		}
+	}()
+
+	// Let external controllers clean up before the VM is destroyed. The Machine is watched, so the deletion resumes
+	// once the last hook annotation is removed.
+	if ctx.Machine != nil && annotations.HasWithPrefix(clusterv1.PreTerminateDeleteHookAnnotationPrefix, ctx.Machine.Annotations) {
+		ctx.Logger.Info("Waiting for the pre-terminate hooks of the machine...")
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForPreTerminateHookReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for the pre-terminate hooks of the machine before deleting the VM")
+		return ctrl.Result{}, nil
+	}
+
+	// Machines created before the infra resource name prefix was recorded need the KubevirtCluster to find their
+	// objects, which would be left behind when removing the finalizer.
+	if !ctx.HasInfraResourceNames() {
+		ctx.Logger.Info("Waiting for the KubevirtCluster to find the VM of the machine...")
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.InfraResourceNamesUnknownReason, clusterv1.ConditionSeverityWarning,
+			"The KubevirtCluster is required to find the objects of the machine in the infra cluster")
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
 	infraClusterClient, infraClusterNamespace, err := r.InfraCluster.GenerateInfraClusterClient(ctx.KubevirtMachine.Spec.InfraClusterSecretRef, ctx.KubevirtMachine.Namespace, ctx.Context)
 	if err != nil {
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to generate infra cluster client")
@@ -403,11 +1245,32 @@ func (r *KubevirtMachineReconciler) reconcileDelete(ctx *context.MachineContext)
 		vmNamespace = infraClusterNamespace
 	}
 
+	// The objects of a terminating namespace are deleted by the namespace controller,
+	// so just wait for the VM to be gone.
+	if terminating, err := isInfraNamespaceTerminating(ctx, infraClusterClient, vmNamespace); err != nil {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
+	} else if terminating {
+		vm := &kubevirtv1.VirtualMachine{}
+		if err := infraClusterClient.Get(ctx.Context, client.ObjectKey{Namespace: vmNamespace, Name: ctx.VMName()}, vm); err == nil {
+			ctx.Logger.Info(fmt.Sprintf("Infra namespace %s is terminating, waiting for VM to be gone...", vmNamespace))
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, infrav1.InfraNamespaceTerminatingReason, clusterv1.ConditionSeverityWarning, "Infra namespace %s is terminating", vmNamespace)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		} else if !apierrors.IsNotFound(err) {
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to get VM")
+		}
+	}
+
 	ctx.Logger.Info("Deleting VM bootstrap secret...")
 	if err := r.deleteKubevirtBootstrapSecret(ctx, infraClusterClient, vmNamespace); err != nil {
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to delete bootstrap secret")
 	}
 
+	if ctx.KubevirtCluster != nil && ctx.KubevirtCluster.Spec.NodeDeletionMode == infrav1.NodeDeletionCordonOnly {
+		if err := r.cordonWorkloadClusterNode(ctx); err != nil {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+		}
+	}
+
 	ctx.Logger.Info("Deleting VM...")
 	externalMachine, err := kubevirthandler.NewMachine(ctx, infraClusterClient, vmNamespace, nil)
 	if err != nil {
@@ -419,19 +1282,79 @@ func (r *KubevirtMachineReconciler) reconcileDelete(ctx *context.MachineContext)
 		}
 	}
 
+	if ctx.KubevirtMachine.Spec.ForceTerminationTimeout != nil {
+		if result, err := r.waitForVMITermination(ctx, infraClusterClient, vmNamespace); err != nil || !result.IsZero() {
+			conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+			return result, err
+		}
+	}
+
+	if result, err := r.deleteWorkloadClusterNode(ctx, infraClusterClient, vmNamespace); err != nil || !result.IsZero() {
+		conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+		return result, err
+	}
+
 	// Machine is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(ctx.KubevirtMachine, infrav1.MachineFinalizer)
 
-	// Set the VMProvisionedCondition reporting delete is started, and issue a patch in order to make
-	// this visible to the users.
+	// Set the VMProvisionedCondition reporting delete is started, which is patched along with the removal of the
+	// finalizer in order to make this visible to the users.
 	conditions.MarkFalse(ctx.KubevirtMachine, infrav1.VMProvisionedCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
-	if err := ctx.PatchKubevirtMachine(patchHelper); err != nil {
-		return ctrl.Result{}, errors.Wrap(err, "failed to patch KubevirtMachine")
-	}
 
 	return ctrl.Result{}, nil
 }
 
+// isInfraNamespaceTerminating checks if the namespace of the VM in the infra cluster is being deleted.
+// Infra cluster credentials which are not allowed to read the namespace are assumed to target a live namespace.
+func isInfraNamespaceTerminating(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) (bool, error) {
+	namespace := &corev1.Namespace{}
+	if err := infraClusterClient.Get(ctx.Context, client.ObjectKey{Name: vmNamespace}, namespace); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get infra namespace %s", vmNamespace)
+	}
+	return namespace.DeletionTimestamp != nil || namespace.Status.Phase == corev1.NamespaceTerminating, nil
+}
+
+// waitForVMITermination waits for the VMI of the machine to be gone. A VMI stuck terminating for longer than
+// the force termination timeout of the machine, e.g. because of a hung qemu process, is force deleted.
+func (r *KubevirtMachineReconciler) waitForVMITermination(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) (ctrl.Result, error) {
+	vmi := &kubevirtv1.VirtualMachineInstance{}
+	vmiKey := client.ObjectKey{Namespace: vmNamespace, Name: ctx.VMName()}
+	if err := infraClusterClient.Get(ctx.Context, vmiKey, vmi); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to get VMI")
+	}
+
+	// The VMI is deleted along with its VM.
+	if vmi.DeletionTimestamp == nil {
+		ctx.Logger.Info("Waiting for VMI to be deleted...")
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	timeout := ctx.KubevirtMachine.Spec.ForceTerminationTimeout.Duration
+	if terminating := time.Since(vmi.DeletionTimestamp.Time); terminating < timeout {
+		ctx.Logger.Info("Waiting for VMI to terminate...")
+		requeueAfter := timeout - terminating
+		if requeueAfter > 10*time.Second {
+			requeueAfter = 10 * time.Second
+		}
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	ctx.Logger.Info("VMI is stuck terminating, force deleting it...")
+	r.Recorder.Eventf(ctx.KubevirtMachine, corev1.EventTypeWarning, "ForceDeletingVMI", "VMI %s/%s is terminating for more than %s, force deleting it", vmi.Namespace, vmi.Name, timeout)
+	if err := infraClusterClient.Delete(ctx.Context, vmi, client.GracePeriodSeconds(0)); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, errors.Wrap(err, "failed to force delete VMI")
+	}
+
+	// Keep the finalizer until the VMI is confirmed gone.
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
 // SetupWithManager will add watches for this controller.
 func (r *KubevirtMachineReconciler) SetupWithManager(goctx gocontext.Context, mgr ctrl.Manager, options controller.Options) error {
 	clusterToKubevirtMachines, err := util.ClusterToObjectsMapper(mgr.GetClient(), &infrav1.KubevirtMachineList{}, mgr.GetScheme())
@@ -555,7 +1478,7 @@ func (r *KubevirtMachineReconciler) reconcileKubevirtBootstrapSecret(ctx *contex
 
 	// Exit early if exists.
 	bootstrapDataSecret := &corev1.Secret{}
-	bootstrapDataSecretKey := client.ObjectKey{Namespace: vmNamespace, Name: *ctx.Machine.Spec.Bootstrap.DataSecretName + "-userdata"}
+	bootstrapDataSecretKey := client.ObjectKey{Namespace: vmNamespace, Name: ctx.UserDataSecretName()}
 	if err := infraClusterClient.Get(ctx, bootstrapDataSecretKey, bootstrapDataSecret); err == nil {
 		ctx.BootstrapDataSecret = bootstrapDataSecret
 		return nil
@@ -572,20 +1495,41 @@ func (r *KubevirtMachineReconciler) reconcileKubevirtBootstrapSecret(ctx *contex
 		return errors.New("error retrieving bootstrap data: secret value key is missing")
 	}
 
-	if sshKeys != nil && isCloudConfigUserData(value) {
+	kubeletFiles, err := userdata.KubeletExtraArgsFiles(value, ctx.KubevirtMachine.Spec.KubeletExtraArgs)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the kubelet environment files of bootstrap userdata")
+	}
+	value, err = userdata.AddFiles(value, kubeletFiles)
+	if err != nil {
+		return errors.Wrap(err, "failed to add kubelet extra args to bootstrap userdata")
+	}
+
+	trustBundle, err := r.trustBundleCertificates(ctx)
+	if err != nil {
+		return err
+	}
+	value, err = userdata.AddTrustBundle(value, trustBundle)
+	if err != nil {
+		return errors.Wrap(err, "failed to add trust bundle to bootstrap userdata")
+	}
+
+	if sshKeys != nil && userdata.IsCloudConfig(value) {
 		ctx.Logger.Info("Adding users and ssh config to bootstrap userdata...")
 		value = []byte(string(value) + usersCloudConfig(sshKeys.PublicKey))
 	}
 
 	newBootstrapDataSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      s.Name + "-userdata",
+			Name:      ctx.UserDataSecretName(),
 			Namespace: vmNamespace,
 		},
 	}
 	ctx.BootstrapDataSecret = newBootstrapDataSecret
 
-	_, err := controllerutil.CreateOrUpdate(ctx, infraClusterClient, newBootstrapDataSecret, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, infraClusterClient, newBootstrapDataSecret, func() error {
+		newBootstrapDataSecret.Annotations = map[string]string{
+			bootstrapDataHashAnnotation: bootstrapDataHash(s.Data["value"]),
+		}
 		newBootstrapDataSecret.Type = clusterv1.ClusterSecretType
 		newBootstrapDataSecret.Data = map[string][]byte{
 			"userdata": value,
@@ -601,6 +1545,37 @@ func (r *KubevirtMachineReconciler) reconcileKubevirtBootstrapSecret(ctx *contex
 	return nil
 }
 
+// trustBundleCertificates returns the PEM encoded CA certificates of the trust bundle of the cluster.
+func (r *KubevirtMachineReconciler) trustBundleCertificates(ctx *context.MachineContext) ([]string, error) {
+	var certificates []string
+	for _, source := range ctx.KubevirtCluster.Spec.TrustBundle {
+		if source.PEM != "" {
+			certificates = append(certificates, source.PEM)
+		}
+		if source.SecretRef == nil {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: ctx.KubevirtCluster.Namespace, Name: source.SecretRef.Name}
+		if err := r.Client.Get(ctx, key, secret); err != nil {
+			if apierrors.IsNotFound(err) && source.SecretRef.Optional != nil && *source.SecretRef.Optional {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to fetch trust bundle secret %s/%s", key.Namespace, key.Name)
+		}
+		certificate, ok := secret.Data[source.SecretRef.Key]
+		if !ok {
+			if source.SecretRef.Optional != nil && *source.SecretRef.Optional {
+				continue
+			}
+			return nil, errors.Errorf("trust bundle secret %s/%s has no %s key", key.Namespace, key.Name, source.SecretRef.Key)
+		}
+		certificates = append(certificates, string(certificate))
+	}
+	return certificates, nil
+}
+
 // deleteKubevirtBootstrapSecret deletes bootstrap cloud-init secret for KubeVirt virtual machines
 func (r *KubevirtMachineReconciler) deleteKubevirtBootstrapSecret(ctx *context.MachineContext, infraClusterClient client.Client, vmNamespace string) error {
 
@@ -610,7 +1585,7 @@ func (r *KubevirtMachineReconciler) deleteKubevirtBootstrapSecret(ctx *context.M
 	}
 
 	bootstrapDataSecret := &corev1.Secret{}
-	bootstrapDataSecretKey := client.ObjectKey{Namespace: vmNamespace, Name: *ctx.Machine.Spec.Bootstrap.DataSecretName + "-userdata"}
+	bootstrapDataSecretKey := client.ObjectKey{Namespace: vmNamespace, Name: ctx.UserDataSecretName()}
 	if err := infraClusterClient.Get(ctx, bootstrapDataSecretKey, bootstrapDataSecret); err != nil {
 		// the secret does not exist, exit without error
 		return nil
@@ -623,10 +1598,6 @@ func (r *KubevirtMachineReconciler) deleteKubevirtBootstrapSecret(ctx *context.M
 	return nil
 }
 
-func isCloudConfigUserData(userData []byte) bool {
-	return regexp.MustCompile(`(?m)^#cloud-config`).MatchString(string(userData))
-}
-
 // usersCloudConfig generates 'users' cloud config for capk user with a given ssh public key
 func usersCloudConfig(sshPublicKey []byte) string {
 	sshPublicKeyString := base64.StdEncoding.EncodeToString(sshPublicKey)
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	gocontext "context"
+	"encoding/base64"
 	"time"
 
 	"github.com/golang/mock/gomock"
@@ -28,10 +29,17 @@ import (
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/kubevirt"
 
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	flavorv1alpha1 "kubevirt.io/api/flavor/v1alpha1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -45,6 +53,9 @@ import (
 	infrav1 "sigs.k8s.io/cluster-api-provider-kubevirt/api/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/context"
 	infraclustermock "sigs.k8s.io/cluster-api-provider-kubevirt/pkg/infracluster/mock"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/notification"
+	notificationmock "sigs.k8s.io/cluster-api-provider-kubevirt/pkg/notification/mock"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/semaphore"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/testing"
 	workloadclustermock "sigs.k8s.io/cluster-api-provider-kubevirt/pkg/workloadcluster/mock"
 )
@@ -135,16 +146,6 @@ var _ = Describe("KubevirtClusterToKubevirtMachines", func() {
 	})
 })
 
-var _ = Describe("utility functions", func() {
-	DescribeTable("should detect userdata is cloud-config", func(userData []byte, expected bool) {
-		Expect(isCloudConfigUserData(userData)).To(Equal(expected))
-	},
-		Entry("should detect cloud-config", []byte("#something\n\n#something else\n#cloud-config\nthe end"), true),
-		Entry("should not detect cloud-config", []byte("#something\n\n#something else\n#not-cloud-config\nthe end"), false),
-		Entry("should not detect cloud-config", []byte("#something\n\n#something else\n   #cloud-config\nthe end"), false),
-	)
-})
-
 var _ = Describe("reconcile a kubevirt machine", func() {
 	var (
 		mockCtrl            *gomock.Controller
@@ -291,6 +292,180 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 		Expect(machineContext.KubevirtMachine.Spec.ProviderID).To(BeNil())
 	})
 
+	It("should enforce the maximum number of VMs provisioned at once across clusters", func() {
+		otherNamespace := "other-cluster"
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+		}
+		otherObjects := []client.Object{}
+		for _, object := range objects {
+			otherObject := object.DeepCopyObject().(client.Object)
+			otherObject.SetNamespace(otherNamespace)
+			otherObjects = append(otherObjects, otherObject)
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, append(objects, otherObjects...))
+		kubevirtMachineReconciler.VMCreations = semaphore.New(1)
+		otherMachineContext := &context.MachineContext{
+			Context:         gocontext.Background(),
+			Cluster:         otherObjects[0].(*clusterv1.Cluster),
+			KubevirtCluster: otherObjects[1].(*infrav1.KubevirtCluster),
+			Machine:         otherObjects[2].(*clusterv1.Machine),
+			KubevirtMachine: otherObjects[3].(*infrav1.KubevirtMachine),
+			Logger:          testLogger,
+		}
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, otherNamespace, otherMachineContext.Context).Return(fakeClient, otherNamespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+		vm := &kubevirtv1.VirtualMachine{}
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}, vm)).To(Succeed())
+
+		out, err = kubevirtMachineReconciler.reconcileNormal(otherMachineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+		Expect(conditions.GetReason(otherMachineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.WaitingForGlobalCapacityReason))
+		err = fakeClient.Get(gocontext.Background(), client.ObjectKey{Namespace: otherNamespace, Name: kubevirtMachine.Name}, vm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should release the provisioning permit of a VM not ready after the provisioning timeout", func() {
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+		kubevirtMachineReconciler.VMCreations = semaphore.New(1)
+		kubevirtMachineReconciler.VMProvisioningTimeout = time.Nanosecond
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.VMProvisioningReason))
+		Expect(kubevirtMachineReconciler.VMCreations.TryAcquire("other/machine")).To(BeFalse())
+
+		_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(kubevirtMachineReconciler.VMCreations.TryAcquire("other/machine")).To(BeTrue())
+	})
+
+	It("should restore the provisioning permits of the VMs not ready yet", func() {
+		provisioningMachine := kubevirtMachine.DeepCopy()
+		conditions.MarkFalse(provisioningMachine, infrav1.VMProvisionedCondition, infrav1.VMProvisioningReason, clusterv1.ConditionSeverityInfo, "")
+		waitingMachine := kubevirtMachine.DeepCopy()
+		waitingMachine.Name = "waiting-machine"
+		conditions.MarkFalse(waitingMachine, infrav1.VMProvisionedCondition, infrav1.WaitingForGlobalCapacityReason, clusterv1.ConditionSeverityInfo, "")
+		provisionedMachine := kubevirtMachine.DeepCopy()
+		provisionedMachine.Name = "provisioned-machine"
+		conditions.MarkTrue(provisionedMachine, infrav1.VMProvisionedCondition)
+
+		setupClient(kubevirt.DefaultMachineFactory{}, []client.Object{provisioningMachine, waitingMachine, provisionedMachine})
+		kubevirtMachineReconciler.VMCreations = semaphore.New(1)
+
+		Expect(kubevirtMachineReconciler.restoreVMCreations(gocontext.Background())).To(Succeed())
+		_, held := kubevirtMachineReconciler.VMCreations.HeldSince(kubevirtMachine.Namespace + "/" + kubevirtMachine.Name)
+		Expect(held).To(BeTrue())
+		Expect(kubevirtMachineReconciler.VMCreations.TryAcquire(waitingMachine.Namespace + "/" + waitingMachine.Name)).To(BeFalse())
+	})
+
+	It("should report and back off from a throttled infra cluster client", func() {
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(&throttledClient{Client: fakeClient}, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+
+		throttledCondition := conditions.Get(machineContext.KubevirtMachine, infrav1.ThrottledByInfraAPICondition)
+		Expect(throttledCondition).ToNot(BeNil())
+		Expect(throttledCondition.Status).To(Equal(corev1.ConditionTrue))
+		Expect(throttledCondition.Reason).To(Equal(infrav1.ClientSideRateLimitedReason))
+
+		// the VM is created anyway
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		Expect(fakeClient.Get(gocontext.Background(), vmKey, vm)).To(Succeed())
+
+		// the condition is removed once the client is not throttled anymore
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conditions.Has(machineContext.KubevirtMachine, infrav1.ThrottledByInfraAPICondition)).To(BeFalse())
+	})
+
+	It("should reconcile the providerID of a node rejoining after its VM got recreated", func() {
+		providerID := "kubevirt://" + kubevirtMachineName
+		kubevirtMachine.Spec.ProviderID = &providerID
+		kubevirtMachine.Status.NodeUpdated = true
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		// the VM is gone, so it gets recreated and the node has to be reconciled again
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+		Expect(machineContext.KubevirtMachine.Status.NodeUpdated).To(BeFalse())
+
+		// the node of the previous VM still exists and rejoins with the same identity
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: kubevirtMachine.Namespace,
+				Name:      kubevirtMachineName,
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: providerID,
+			},
+		}
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(node).Build()
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+
+		out, err = kubevirtMachineReconciler.updateNodeProviderID(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(machineContext.KubevirtMachine.Status.NodeUpdated).To(BeTrue())
+
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Spec.ProviderID).To(Equal(providerID))
+	})
+
 	It("should ensure deletion of KubevirtMachine garbage collects everything successfully", func() {
 		objects := []client.Object{
 			cluster,
@@ -361,10 +536,11 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 		Expect(len(machineContext.Machine.ObjectMeta.Finalizers)).To(Equal(0))
 	})
 
-	It("should update userdata correctly at KubevirtMachine reconcile", func() {
-		//Get Machine
-		//Get userdata secret name from machine
-		//Get userdata secret and assert equality to original secret
+	It("should wait for the pre-terminate hooks of the machine before deleting the VM", func() {
+		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+		machine.Annotations = map[string]string{clusterv1.PreTerminateDeleteHookAnnotationPrefix + "/cleanup": "cleanup-controller"}
+		vm.Namespace = kubevirtMachine.Namespace
+
 		objects := []client.Object{
 			cluster,
 			kubevirtCluster,
@@ -373,73 +549,199 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 			sshKeySecret,
 			bootstrapSecret,
 			bootstrapUserDataSecret,
+			vm,
 		}
 
-		setupClient(kubevirt.DefaultMachineFactory{}, objects)
-
-		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+		setupClient(machineFactoryMock, objects)
 
-		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeTrue())
+		persistedMachine := &infrav1.KubevirtMachine{}
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(kubevirtMachine), persistedMachine)).To(Succeed())
+		Expect(conditions.GetReason(persistedMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.WaitingForPreTerminateHookReason))
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm)).To(Succeed())
+
+		// the deletion proceeds once the hook is removed
+		machine.Annotations = nil
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
 
+		out, err = kubevirtMachineReconciler.reconcileDelete(machineContext)
 		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm))).To(BeTrue())
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeFalse())
+	})
 
-		// should expect to re-enqueue while waiting for VMI to come online
-		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+	It("should halt a VM with the Always run strategy so that its VMI is not recreated while it is deleted", func() {
+		always := kubevirtv1.RunStrategyAlways
+		vm := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  kubevirtMachine.Namespace,
+				Name:       kubevirtMachineName,
+				Finalizers: []string{"kubevirt.io/virtualMachineControllerFinalize"},
+			},
+			Spec: kubevirtv1.VirtualMachineSpec{RunStrategy: &always},
+		}
 
-		// should expect VM to be created with expected name
-		vm := &kubevirtv1.VirtualMachine{}
-		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
-		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
-		Expect(err).NotTo(HaveOccurred())
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			vm,
+			vmi,
+		}
 
-		machineBootstrapSecretReferenceName := machineContext.Machine.Spec.Bootstrap.DataSecretName
-		machineBootstrapSecretReferenceKey := client.ObjectKey{Namespace: machineContext.Machine.GetNamespace(), Name: *machineBootstrapSecretReferenceName + "-userdata"}
-		infraClusterClient, _, err := infraClusterMock.GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context)
-		Expect(err).NotTo(HaveOccurred())
+		setupClient(machineFactoryMock, objects)
 
-		bootstrapDataSecret := &corev1.Secret{}
-		err = infraClusterClient.Get(gocontext.Background(), machineBootstrapSecretReferenceKey, bootstrapDataSecret)
-		Expect(err).NotTo(HaveOccurred())
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
 
-		Expect(bootstrapUserDataSecret.Data["userdata"]).To(Equal([]byte("shell-script")))
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+
+		// the terminating VM is halted, so KubeVirt does not start a new VMI once the current one is gone
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm)).To(Succeed())
+		Expect(vm.DeletionTimestamp).NotTo(BeNil())
+		Expect(vm.Spec.RunStrategy).NotTo(BeNil())
+		Expect(*vm.Spec.RunStrategy).To(Equal(kubevirtv1.RunStrategyHalted))
+
+		// the VM stays halted while it is terminating
+		out, err = kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm)).To(Succeed())
+		Expect(*vm.Spec.RunStrategy).To(Equal(kubevirtv1.RunStrategyHalted))
 	})
 
-	It("should be able to delete KubeVirt VM even when cluster objects don't exist", func() {
+	It("should force delete a VMI stuck terminating once the force termination timeout expires", func() {
+		kubevirtMachine.Spec.ForceTerminationTimeout = &metav1.Duration{Duration: time.Minute}
 		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+
+		terminatingSince := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		stuckVMI := &kubevirtv1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         kubevirtMachine.Namespace,
+				Name:              kubevirtMachineName,
+				DeletionTimestamp: &terminatingSince,
+				Finalizers:        []string{"foregroundDeleteVirtualMachine"},
+			},
+		}
+
 		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
 			machine,
 			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
 			bootstrapUserDataSecret,
-			vm,
+			stuckVMI,
 		}
 
 		setupClient(machineFactoryMock, objects)
+		recorder := record.NewFakeRecorder(10)
+		kubevirtMachineReconciler.Recorder = recorder
 
-		machineContext = &context.MachineContext{
-			Context:         gocontext.Background(),
-			Machine:         machine,
-			KubevirtMachine: kubevirtMachine,
-			Logger:          testLogger,
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ForceDeletingVMI")))
+
+		// the finalizer is kept until the VMI is gone
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeTrue())
+		persistedMachine := &infrav1.KubevirtMachine{}
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(kubevirtMachine), persistedMachine)).To(Succeed())
+		Expect(conditions.GetReason(persistedMachine, infrav1.VMProvisionedCondition)).To(Equal(clusterv1.DeletingReason))
+
+		// the VMI goes away once its virt-launcher pod is killed
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(stuckVMI), stuckVMI)).To(Succeed())
+		stuckVMI.Finalizers = nil
+		Expect(fakeClient.Update(gocontext.Background(), stuckVMI)).To(Succeed())
+
+		out, err = kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeFalse())
+	})
+
+	It("should delete the workload cluster node and its volume attachments once the VM is gone", func() {
+		providerID := "kubevirt://" + kubevirtMachineName
+		kubevirtMachine.Spec.ProviderID = &providerID
+		kubevirtMachine.Status.NodeUpdated = true
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: kubevirtMachineName},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+		pvName := "pvc-1234"
+		nodeVolumeAttachment := &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-node"},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "csi.kubevirt.io",
+				NodeName: kubevirtMachineName,
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+			},
+		}
+		otherVolumeAttachment := &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-other-node"},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "csi.kubevirt.io",
+				NodeName: "other-node",
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+			},
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
 		}
 
+		setupClient(machineFactoryMock, objects)
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(node, nodeVolumeAttachment, otherVolumeAttachment).Build()
+
 		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
 
 		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(out).To(Equal(ctrl.Result{}))
 
-		// vm should be deleted
-		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
-		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
-		Expect(err).To(HaveOccurred())
-
-		Expect(machineContext.Machine.ObjectMeta.Finalizers).To(HaveLen(0))
+		Expect(apierrors.IsNotFound(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(node), node))).To(BeTrue())
+		Expect(apierrors.IsNotFound(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(nodeVolumeAttachment), nodeVolumeAttachment))).To(BeTrue())
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(otherVolumeAttachment), otherVolumeAttachment)).To(Succeed())
+		Expect(machineContext.KubevirtMachine.Status.NodeUpdated).To(BeFalse())
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeFalse())
 	})
 
-	It("should create KubeVirt VM with externally managed cluster and no ssh key", func() {
+	It("should cordon the workload cluster node without draining it before deleting the VM in CordonOnly mode", func() {
+		kubevirtCluster.Spec.NodeDeletionMode = infrav1.NodeDeletionCordonOnly
+		providerID := "kubevirt://" + kubevirtMachineName
+		kubevirtMachine.Spec.ProviderID = &providerID
+		kubevirtMachine.Status.NodeUpdated = true
 
-		kubevirtCluster.Annotations = map[string]string{
-			"cluster.x-k8s.io/managed-by": "external",
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: kubevirtMachineName},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+		nodePod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "workload"},
+			Spec:       corev1.PodSpec{NodeName: kubevirtMachineName},
+		}
+		vm := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachineName},
 		}
 
 		objects := []client.Object{
@@ -447,36 +749,33 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 			kubevirtCluster,
 			machine,
 			kubevirtMachine,
+			sshKeySecret,
 			bootstrapSecret,
 			bootstrapUserDataSecret,
+			vm,
+			vmi,
 		}
 
-		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+		setupClient(machineFactoryMock, objects)
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(node, nodePod).Build()
 
 		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
 
-		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
-
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
 		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
 
-		// should expect to re-enqueue while waiting for VMI to come online
-		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
-
-		// should expect VM to be created with expected name
-		vm := &kubevirtv1.VirtualMachine{}
-		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
-		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
-		Expect(err).NotTo(HaveOccurred())
-
-		// Should expect kubevirt machine is still not ready
-		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
-		Expect(machineContext.KubevirtMachine.Spec.ProviderID).To(BeNil())
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Spec.Unschedulable).To(BeTrue())
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(nodePod), nodePod)).To(Succeed())
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm))).To(BeTrue())
 	})
 
-	It("should create KubeVirt VM in custom namespace", func() {
-
-		customNamespace := "custom"
-		kubevirtMachine.Spec.VirtualMachineTemplate.ObjectMeta.Namespace = customNamespace
+	It("should wait for the VM to shut down before deleting the workload cluster node", func() {
+		providerID := "kubevirt://" + kubevirtMachineName
+		kubevirtMachine.Spec.ProviderID = &providerID
+		kubevirtMachine.Status.NodeUpdated = true
 
 		objects := []client.Object{
 			cluster,
@@ -486,31 +785,933 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 			sshKeySecret,
 			bootstrapSecret,
 			bootstrapUserDataSecret,
+			vmi,
 		}
 
-		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+		setupClient(machineFactoryMock, objects)
 
 		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
 
-		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
-
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
 		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeTrue())
+		persistedMachine := &infrav1.KubevirtMachine{}
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(kubevirtMachine), persistedMachine)).To(Succeed())
+		Expect(conditions.GetReason(persistedMachine, infrav1.VMProvisionedCondition)).To(Equal(clusterv1.DeletingReason))
+	})
 
-		// should expect to re-enqueue while waiting for VMI to come online
-		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+	It("should report the DataVolumes of the VM and their phases", func() {
+		dataVolumeName := kubevirtMachineName + "-rootdisk"
+		vm := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachineName},
+			Spec: kubevirtv1.VirtualMachineSpec{
+				DataVolumeTemplates: []kubevirtv1.DataVolumeTemplateSpec{{ObjectMeta: metav1.ObjectMeta{Name: dataVolumeName}}},
+			},
+		}
+		dataVolume := &cdiv1.DataVolume{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: dataVolumeName},
+			Status:     cdiv1.DataVolumeStatus{Phase: cdiv1.ImportInProgress},
+		}
+		claim := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: dataVolumeName},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			vm,
+			dataVolume,
+			claim,
+		}
+
+		machineMock.EXPECT().Exists().Return(true).AnyTimes()
+		machineMock.EXPECT().IsReady().Return(false).AnyTimes()
+		machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).AnyTimes()
+
+		setupClient(machineFactoryMock, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).AnyTimes()
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(machineContext.KubevirtMachine.Status.Volumes).To(Equal([]infrav1.MachineVolume{
+			{Name: dataVolumeName, DataVolumePhase: cdiv1.ImportInProgress, ClaimPhase: corev1.ClaimPending},
+		}))
+
+		dataVolume.Status.Phase = cdiv1.Succeeded
+		Expect(fakeClient.Update(gocontext.Background(), dataVolume)).To(Succeed())
+		claim.Status.Phase = corev1.ClaimBound
+		Expect(fakeClient.Update(gocontext.Background(), claim)).To(Succeed())
+
+		_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(machineContext.KubevirtMachine.Status.Volumes).To(Equal([]infrav1.MachineVolume{
+			{Name: dataVolumeName, DataVolumePhase: cdiv1.Succeeded, ClaimPhase: corev1.ClaimBound},
+		}))
+	})
+
+	It("should wait for the startup probe of a slow booting VM without failing the machine", func() {
+		kubevirtMachine.Spec.StartupProbe = &kubevirtv1.Probe{
+			Handler:          kubevirtv1.Handler{GuestAgentPing: &kubevirtv1.GuestAgentPing{}},
+			FailureThreshold: 60,
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		machineMock.EXPECT().Exists().Return(true)
+		machineMock.EXPECT().IsReady().Return(false)
+		machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil)
+
+		setupClient(machineFactoryMock, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+		Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.WaitingForStartupProbeReason))
+		Expect(conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition).Severity).To(Equal(clusterv1.ConditionSeverityInfo))
+		Expect(isProvisioningFailed(machineContext.KubevirtMachine)).To(BeFalse())
+	})
+
+	It("should update userdata correctly at KubevirtMachine reconcile", func() {
+		//Get Machine
+		//Get userdata secret name from machine
+		//Get userdata secret and assert equality to original secret
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// should expect to re-enqueue while waiting for VMI to come online
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		// should expect VM to be created with expected name
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(err).NotTo(HaveOccurred())
+
+		machineBootstrapSecretReferenceName := machineContext.Machine.Spec.Bootstrap.DataSecretName
+		machineBootstrapSecretReferenceKey := client.ObjectKey{Namespace: machineContext.Machine.GetNamespace(), Name: *machineBootstrapSecretReferenceName + "-userdata"}
+		infraClusterClient, _, err := infraClusterMock.GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context)
+		Expect(err).NotTo(HaveOccurred())
+
+		bootstrapDataSecret := &corev1.Secret{}
+		err = infraClusterClient.Get(gocontext.Background(), machineBootstrapSecretReferenceKey, bootstrapDataSecret)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bootstrapUserDataSecret.Data["userdata"]).To(Equal([]byte("shell-script")))
+	})
+
+	It("should add kubelet extra args to the cloud-config userdata", func() {
+		kubevirtMachine.Spec.KubeletExtraArgs = map[string]string{"cloud-provider": "external"}
+		bootstrapSecret.Data["value"] = []byte("## template: jinja\n#cloud-config\n\nruncmd:\n  - \"kubeadm join\"\n")
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		userDataSecret := &corev1.Secret{}
+		userDataSecretKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: bootstrapSecretName + "-userdata"}
+		Expect(fakeClient.Get(gocontext.Background(), userDataSecretKey, userDataSecret)).To(Succeed())
+
+		value := string(userDataSecret.Data["userdata"])
+		Expect(value).To(HavePrefix("## template: jinja\n#cloud-config\n\nruncmd:\n  - \"kubeadm join\"\n"))
+		Expect(value).To(ContainSubstring("path: /etc/default/kubelet"))
+		Expect(value).To(ContainSubstring(base64.StdEncoding.EncodeToString([]byte("KUBELET_EXTRA_ARGS=\"--cloud-provider=external\"\n"))))
+	})
+
+	It("should emit a warning event when the cache mode of a disk is overridden", func() {
+		kubevirtCluster.Spec.DiskCacheMode = kubevirtv1.CacheNone
+		vmiSpec := &kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec
+		vmiSpec.Volumes = []kubevirtv1.Volume{
+			{Name: "rootdisk", VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: "root"}}},
+		}
+		vmiSpec.Domain.Devices.Disks = []kubevirtv1.Disk{{Name: "rootdisk", Cache: kubevirtv1.CacheWriteThrough}}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+		recorder := record.NewFakeRecorder(10)
+		kubevirtMachineReconciler.Recorder = recorder
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring("Warning DiskCacheModeOverridden"),
+			ContainSubstring("Cache mode writethrough of disk rootdisk is overridden with the cache mode none"),
+		)))
+	})
+
+	It("should add the cluster trust bundle to the cloud-config userdata", func() {
+		kubevirtCluster.Spec.TrustBundle = []infrav1.TrustBundleSource{
+			{PEM: "registry-ca"},
+			{SecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "proxy-ca"}, Key: "ca.crt"}},
+		}
+		bootstrapSecret.Data["value"] = []byte("## template: jinja\n#cloud-config\n\nruncmd:\n  - \"kubeadm join\"\n")
+		proxyCASecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtCluster.Namespace, Name: "proxy-ca"},
+			Data:       map[string][]byte{"ca.crt": []byte("proxy-ca")},
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			proxyCASecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		userDataSecret := &corev1.Secret{}
+		userDataSecretKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: bootstrapSecretName + "-userdata"}
+		Expect(fakeClient.Get(gocontext.Background(), userDataSecretKey, userDataSecret)).To(Succeed())
+
+		value := string(userDataSecret.Data["userdata"])
+		Expect(value).To(HavePrefix("## template: jinja\n#cloud-config\n\nruncmd:\n  - \"kubeadm join\"\n" +
+			"ca_certs:\n  trusted:\n  - \"registry-ca\"\n  - \"proxy-ca\"\n"))
+	})
+
+	It("should be able to delete KubeVirt VM even when cluster objects don't exist", func() {
+		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+		noPrefix := ""
+		kubevirtMachine.Status.InfraResourceNamePrefix = &noPrefix
+		objects := []client.Object{
+			machine,
+			kubevirtMachine,
+			bootstrapUserDataSecret,
+			vm,
+		}
+
+		setupClient(machineFactoryMock, objects)
+
+		machineContext = &context.MachineContext{
+			Context:         gocontext.Background(),
+			Machine:         machine,
+			KubevirtMachine: kubevirtMachine,
+			Logger:          testLogger,
+		}
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+
+		// vm should be deleted
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(err).To(HaveOccurred())
+
+		Expect(machineContext.Machine.ObjectMeta.Finalizers).To(HaveLen(0))
+	})
+
+	It("should delete the prefixed VM recorded in the status once the cluster objects are gone", func() {
+		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+		prefix := "mgmt1"
+		kubevirtMachine.Status.InfraResourceNamePrefix = &prefix
+		prefixedVM := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: "mgmt1-" + kubevirtMachineName},
+		}
+		prefixedUserDataSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: kubevirtMachine.Namespace, Name: "mgmt1-" + bootstrapSecretName + "-userdata"},
+		}
+		objects := []client.Object{
+			machine,
+			kubevirtMachine,
+			prefixedUserDataSecret,
+			prefixedVM,
+		}
+
+		setupClient(machineFactoryMock, objects)
+
+		machineContext = &context.MachineContext{
+			Context:         gocontext.Background(),
+			Machine:         machine,
+			KubevirtMachine: kubevirtMachine,
+			Logger:          testLogger,
+		}
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(prefixedVM), prefixedVM))).To(BeTrue())
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(prefixedUserDataSecret), prefixedUserDataSecret))).To(BeTrue())
+		Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeFalse())
+	})
+
+	It("should keep the finalizer when the VM of the machine cannot be found without the cluster objects", func() {
+		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+		objects := []client.Object{
+			machine,
+			kubevirtMachine,
+			vm,
+		}
+
+		setupClient(machineFactoryMock, objects)
+
+		machineContext = &context.MachineContext{
+			Context:         gocontext.Background(),
+			Machine:         machine,
+			KubevirtMachine: kubevirtMachine,
+			Logger:          testLogger,
+		}
+
+		out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 30 * time.Second}))
+
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(vm), vm)).To(Succeed())
+		persistedMachine := &infrav1.KubevirtMachine{}
+		Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(kubevirtMachine), persistedMachine)).To(Succeed())
+		Expect(controllerutil.ContainsFinalizer(persistedMachine, infrav1.MachineFinalizer)).To(BeTrue())
+		Expect(conditions.GetReason(persistedMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.InfraResourceNamesUnknownReason))
+	})
+
+	It("should create KubeVirt VM with externally managed cluster and no ssh key", func() {
+
+		kubevirtCluster.Annotations = map[string]string{
+			"cluster.x-k8s.io/managed-by": "external",
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// should expect to re-enqueue while waiting for VMI to come online
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		// should expect VM to be created with expected name
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Should expect kubevirt machine is still not ready
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+		Expect(machineContext.KubevirtMachine.Spec.ProviderID).To(BeNil())
+	})
+
+	It("should neither inject the ssh key nor check the bootstrap over ssh when ssh key injection is disabled", func() {
+		kubevirtCluster.Spec.DisableSSHKeyInjection = true
+		bootstrapSecret.Data["value"] = []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+		vmi.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{
+			{
+				Type:   kubevirtv1.VirtualMachineInstanceReady,
+				Status: corev1.ConditionTrue,
+			},
+		}
+		vmi.Status.Interfaces = []kubevirtv1.VirtualMachineInstanceNetworkInterface{{IP: "1.1.1.1"}}
+
+		// the ssh keys secret of the cluster does not exist
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			bootstrapSecret,
+			vm,
+			vmi,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+
+		userDataSecret := &corev1.Secret{}
+		userDataSecretKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: bootstrapSecretName + "-userdata"}
+		Expect(fakeClient.Get(gocontext.Background(), userDataSecretKey, userDataSecret)).To(Succeed())
+		Expect(string(userDataSecret.Data["userdata"])).NotTo(ContainSubstring("ssh_authorized_keys"))
+
+		Expect(conditions.Has(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)).To(BeFalse())
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+	})
+
+	It("should create KubeVirt VM in custom namespace", func() {
+
+		customNamespace := "custom"
+		kubevirtMachine.Spec.VirtualMachineTemplate.ObjectMeta.Namespace = customNamespace
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// should expect to re-enqueue while waiting for VMI to come online
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		// should expect VM to be created with expected name
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: customNamespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Should expect kubevirt machine is still not ready
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+		Expect(machineContext.KubevirtMachine.Spec.ProviderID).To(BeNil())
+	})
+
+	Context("with a terminating infra namespace", func() {
+		var terminatingNamespace *corev1.Namespace
+
+		BeforeEach(func() {
+			terminatingNamespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "terminating"},
+				Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+			}
+			kubevirtMachine.Spec.VirtualMachineTemplate.ObjectMeta.Namespace = terminatingNamespace.Name
+		})
+
+		It("should not create the VM", func() {
+			objects := []client.Object{
+				cluster,
+				kubevirtCluster,
+				machine,
+				kubevirtMachine,
+				sshKeySecret,
+				bootstrapSecret,
+				terminatingNamespace,
+			}
+
+			setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+			infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+			out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out).To(Equal(ctrl.Result{RequeueAfter: 30 * time.Second}))
+
+			condition := conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal(infrav1.InfraNamespaceTerminatingReason))
+			Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+
+			vms := &kubevirtv1.VirtualMachineList{}
+			Expect(fakeClient.List(gocontext.Background(), vms)).To(Succeed())
+			Expect(vms.Items).To(BeEmpty())
+			secrets := &corev1.SecretList{}
+			Expect(fakeClient.List(gocontext.Background(), secrets, client.InNamespace(terminatingNamespace.Name))).To(Succeed())
+			Expect(secrets.Items).To(BeEmpty())
+		})
+
+		It("should remove the finalizer once the VM is gone", func() {
+			terminatingVM := &kubevirtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: terminatingNamespace.Name,
+					Name:      kubevirtMachineName,
+				},
+			}
+			objects := []client.Object{
+				cluster,
+				kubevirtCluster,
+				machine,
+				kubevirtMachine,
+				sshKeySecret,
+				bootstrapSecret,
+				terminatingNamespace,
+				terminatingVM,
+			}
+
+			setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+			infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+
+			out, err := kubevirtMachineReconciler.reconcileDelete(machineContext)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out).To(Equal(ctrl.Result{RequeueAfter: 10 * time.Second}))
+			Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeTrue())
+			persistedMachine := &infrav1.KubevirtMachine{}
+			Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(kubevirtMachine), persistedMachine)).To(Succeed())
+			Expect(conditions.GetReason(persistedMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.InfraNamespaceTerminatingReason))
+			Expect(conditions.Get(persistedMachine, infrav1.VMProvisionedCondition).Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+
+			// the namespace controller deletes the VM
+			Expect(fakeClient.Delete(gocontext.Background(), terminatingVM)).To(Succeed())
+
+			out, err = kubevirtMachineReconciler.reconcileDelete(machineContext)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(out).To(Equal(ctrl.Result{}))
+			Expect(controllerutil.ContainsFinalizer(machineContext.KubevirtMachine, infrav1.MachineFinalizer)).To(BeFalse())
+		})
+	})
+
+	It("should consistently use the infra resource name prefix of the cluster", func() {
+		kubevirtCluster.Spec.InfraResourceNamePrefix = "mgmt1"
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.DataVolumeTemplates = []kubevirtv1.DataVolumeTemplateSpec{
+			{ObjectMeta: metav1.ObjectMeta{Name: "rootdisk"}},
+		}
+		controllerutil.AddFinalizer(kubevirtMachine, infrav1.MachineFinalizer)
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(3)
+
+		// the prefix is recorded by Reconcile before the objects of the machine are created
+		machineContext.RecordInfraResourceNamePrefix()
+		Expect(*machineContext.KubevirtMachine.Status.InfraResourceNamePrefix).To(Equal("mgmt1"))
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		userDataSecret := &corev1.Secret{}
+		userDataSecretKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: "mgmt1-" + bootstrapSecretName + "-userdata"}
+		Expect(fakeClient.Get(gocontext.Background(), userDataSecretKey, userDataSecret)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: "mgmt1-" + kubevirtMachineName}
+		Expect(fakeClient.Get(gocontext.Background(), vmKey, vm)).To(Succeed())
+		Expect(vm.Spec.DataVolumeTemplates[0].Name).To(Equal("mgmt1-" + kubevirtMachineName + "-rootdisk"))
+		Expect(vm.Spec.Template.Spec.Hostname).To(Equal(kubevirtMachineName))
+		Expect(vm.Spec.Template.Spec.Volumes).To(ContainElement(kubevirtv1.Volume{
+			Name: "cloudinitvolume",
+			VolumeSource: kubevirtv1.VolumeSource{
+				CloudInitConfigDrive: &kubevirtv1.CloudInitConfigDriveSource{
+					UserDataSecretRef: &corev1.LocalObjectReference{Name: userDataSecretKey.Name},
+				},
+			},
+		}))
+
+		// the prefixed VM is found on the next reconcile, rather than being created again
+		out, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+		vms := &kubevirtv1.VirtualMachineList{}
+		Expect(fakeClient.List(gocontext.Background(), vms)).To(Succeed())
+		Expect(vms.Items).To(HaveLen(1))
+
+		// the recorded prefix finds the objects of the machine once the cluster objects are gone
+		machineContext.Cluster = nil
+		machineContext.KubevirtCluster = nil
+		out, err = kubevirtMachineReconciler.reconcileDelete(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), vmKey, vm))).To(BeTrue())
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), userDataSecretKey, userDataSecret))).To(BeTrue())
+	})
+
+	It("should create KubeVirt VM with the priority class when it exists in the infra cluster", func() {
+		kubevirtMachine.Spec.PriorityClassName = "high-priority"
+		priorityClass := &schedulingv1.PriorityClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "high-priority"},
+			Value:      1000,
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			priorityClass,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.Spec.Template.Spec.PriorityClassName).To(Equal("high-priority"))
+	})
+
+	It("should not create KubeVirt VM when the priority class is missing in the infra cluster", func() {
+		kubevirtMachine.Spec.PriorityClassName = "missing-priority"
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		condition := conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(infrav1.PriorityClassNotFoundReason))
+		// the PriorityClass may still be created, the provisioning is not failed
+		Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+		Expect(isProvisioningFailed(machineContext.KubevirtMachine)).To(BeFalse())
+	})
+
+	It("should not create KubeVirt VM when the network interface multi-queue needs more vCPUs", func() {
+		kubevirtMachine.Spec.NetworkInterfaceMultiqueue = infrav1.NetworkInterfaceMultiqueueAuto
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		// the spec has to be fixed, the machine is not requeued
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		condition := conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(infrav1.NetworkInterfaceMultiqueueUnsupportedReason))
+		Expect(condition.Message).To(ContainSubstring("requires at least 2 vCPUs"))
+		Expect(isProvisioningFailed(machineContext.KubevirtMachine)).To(BeTrue())
+	})
+
+	It("should not create KubeVirt VM when the cluster flavor is missing in the infra cluster", func() {
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor = &kubevirtv1.FlavorMatcher{Name: "missing-flavor"}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		condition := conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(infrav1.FlavorNotFoundReason))
+		Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+	})
+
+	It("should not report the cluster flavor as missing when the infra cluster credentials can't read it", func() {
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor = &kubevirtv1.FlavorMatcher{Name: "small"}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			&flavorv1alpha1.VirtualMachineClusterFlavor{ObjectMeta: metav1.ObjectMeta{Name: "small"}},
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(&flavorForbiddenClient{Client: fakeClient}, kubevirtMachine.Namespace, nil)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(apierrors.IsForbidden(errors.Cause(err))).To(BeTrue())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), vmKey, vm))).To(BeTrue())
+		Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).NotTo(Equal(infrav1.FlavorNotFoundReason))
+	})
+
+	It("should create KubeVirt VM when the cluster flavor exists in the infra cluster", func() {
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor = &kubevirtv1.FlavorMatcher{Name: "small"}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			&flavorv1alpha1.VirtualMachineClusterFlavor{ObjectMeta: metav1.ObjectMeta{Name: "small"}},
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		Expect(fakeClient.Get(gocontext.Background(), vmKey, vm)).To(Succeed())
+		Expect(vm.Spec.Flavor).To(Equal(&kubevirtv1.FlavorMatcher{Name: "small"}))
+	})
+
+	It("should detect when VMI is ready and mark KubevirtMachine ready", func() {
+		vmi.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{
+			{
+				Type:   kubevirtv1.VirtualMachineInstanceReady,
+				Status: corev1.ConditionTrue,
+			},
+		}
+		vmi.Status.Interfaces = []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+
+			{
+				IP: "1.1.1.1",
+			},
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			vm,
+			vmi,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// should expect to re-enqueue while waiting for VMI to come online
+		Expect(out).To(Equal(ctrl.Result{}))
 
 		// should expect VM to be created with expected name
 		vm := &kubevirtv1.VirtualMachine{}
-		vmKey := client.ObjectKey{Namespace: customNamespace, Name: kubevirtMachine.Name}
+		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
 		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
 		Expect(err).NotTo(HaveOccurred())
 
-		// Should expect kubevirt machine is still not ready
-		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
-		Expect(machineContext.KubevirtMachine.Spec.ProviderID).To(BeNil())
+		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+		Expect(*machineContext.KubevirtMachine.Spec.ProviderID).To(Equal("kubevirt://" + kubevirtMachineName))
+	})
+
+	It("should report the effective resources of the running VM", func() {
+		// KubeVirt applied a 200% memory overcommit and a CPU allocation ratio of 10 to the VMI
+		vmi.UID = "vmi-uid"
+		vmi.Status.NodeName = "infra-node-1"
+		vmi.Spec.Domain.CPU = &kubevirtv1.CPU{Cores: 4}
+		vmi.Spec.Domain.Memory = &kubevirtv1.Memory{Guest: resource.NewQuantity(4*1024*1024*1024, resource.BinarySI)}
+		vmi.Spec.Domain.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("400m"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		}
+		launcherPod := func(name, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: kubevirtMachine.Namespace,
+					Name:      name,
+					Labels:    map[string]string{kubevirtv1.CreatedByLabel: "vmi-uid"},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: nodeName,
+					Containers: []corev1.Container{
+						{
+							Name: "compute",
+							Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("400m"),
+								corev1.ResourceMemory: resource.MustParse("2276Mi"),
+							}},
+						},
+						{
+							Name: "hotplug-disk",
+							Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+								corev1.ResourceMemory: resource.MustParse("20Mi"),
+							}},
+						},
+					},
+				},
+				Status: corev1.PodStatus{Phase: phase},
+			}
+		}
+		migrationTargetPod := launcherPod("virt-launcher-target", "infra-node-2", corev1.PodPending)
+		migrationTargetPod.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory] = resource.MustParse("3Gi")
+		vmi.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{
+			{
+				Type:   kubevirtv1.VirtualMachineInstanceReady,
+				Status: corev1.ConditionTrue,
+			},
+		}
+		vmi.Status.Interfaces = []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+			{
+				IP: "1.1.1.1",
+			},
+		}
+
+		objects := []client.Object{
+			cluster,
+			kubevirtCluster,
+			machine,
+			kubevirtMachine,
+			sshKeySecret,
+			bootstrapSecret,
+			bootstrapUserDataSecret,
+			vm,
+			vmi,
+			launcherPod("virt-launcher-completed", "infra-node-0", corev1.PodSucceeded),
+			launcherPod("virt-launcher-source", "infra-node-1", corev1.PodRunning),
+			migrationTargetPod,
+		}
+
+		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// the requests of the virt-launcher pod include the overhead of the VM
+		effectiveResources := machineContext.KubevirtMachine.Status.EffectiveResources
+		Expect(effectiveResources).ToNot(BeNil())
+		Expect(effectiveResources.CPURequest.String()).To(Equal("410m"))
+		Expect(effectiveResources.MemoryRequest.String()).To(Equal("2296Mi"))
+		Expect(effectiveResources.GuestMemory.String()).To(Equal("4Gi"))
 	})
 
-	It("should detect when VMI is ready and mark KubevirtMachine ready", func() {
+	It("should notify the machine notification webhook once when the machine becomes ready", func() {
+		kubevirtCluster.Spec.MachineNotificationWebhook = &infrav1.MachineNotificationWebhook{URL: "https://automation.example.com/machines"}
 		vmi.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{
 			{
 				Type:   kubevirtv1.VirtualMachineInstanceReady,
@@ -518,7 +1719,6 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 			},
 		}
 		vmi.Status.Interfaces = []kubevirtv1.VirtualMachineInstanceNetworkInterface{
-
 			{
 				IP: "1.1.1.1",
 			},
@@ -537,25 +1737,30 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 		}
 
 		setupClient(kubevirt.DefaultMachineFactory{}, objects)
+		notifierMock := notificationmock.NewMockNotifier(mockCtrl)
+		kubevirtMachineReconciler.Notifier = notifierMock
 
-		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
-
-		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
-		out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+		infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).Times(2)
+		notifierMock.EXPECT().Notify("https://automation.example.com/machines", notification.MachineEvent{
+			Event:      notification.MachineReadyEvent,
+			Namespace:  kubevirtMachine.Namespace,
+			Name:       kubevirtMachineName,
+			ProviderID: "kubevirt://" + kubevirtMachineName,
+			Addresses: []clusterv1.MachineAddress{
+				{Type: clusterv1.MachineHostName, Address: kubevirtMachineName},
+				{Type: clusterv1.MachineInternalIP, Address: "1.1.1.1"},
+				{Type: clusterv1.MachineExternalIP, Address: "1.1.1.1"},
+				{Type: clusterv1.MachineInternalDNS, Address: kubevirtMachineName},
+			},
+		}).Times(1)
 
+		_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
 		Expect(err).ShouldNot(HaveOccurred())
-
-		// should expect to re-enqueue while waiting for VMI to come online
-		Expect(out).To(Equal(ctrl.Result{}))
-
-		// should expect VM to be created with expected name
-		vm := &kubevirtv1.VirtualMachine{}
-		vmKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
-		err = fakeClient.Get(gocontext.Background(), vmKey, vm)
-		Expect(err).NotTo(HaveOccurred())
-
 		Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
-		Expect(*machineContext.KubevirtMachine.Spec.ProviderID).To(Equal("kubevirt://" + kubevirtMachineName))
+
+		// the machine is already ready, the webhook is not notified again
+		_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
 	})
 
 	Context("update kubevirt machine conditions correctly", func() {
@@ -644,6 +1849,38 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 				Expect(conditions[0].Type).To(Equal(infrav1.VMProvisionedCondition))
 				Expect(conditions[0].Reason).To(Equal(clusterv1.WaitingForControlPlaneAvailableReason))
 			})
+			It("escalates the VMProvisionedCondition once the control plane initialization timeout expires", func() {
+				machine.Spec.Bootstrap.DataSecretName = nil
+				delete(machine.ObjectMeta.Labels, clusterv1.MachineControlPlaneLabelName)
+				conditions.MarkFalse(cluster, clusterv1.ControlPlaneInitializedCondition, "nonce", clusterv1.ConditionSeverityInfo, "")
+				kubevirtCluster.Spec.ControlPlaneInitializationTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+
+				objects := []client.Object{
+					cluster,
+					kubevirtCluster,
+					machine,
+					kubevirtMachine,
+				}
+
+				setupClient(kubevirt.DefaultMachineFactory{}, objects)
+
+				machineContext.KubevirtMachine.CreationTimestamp = metav1.NewTime(time.Now().Add(-5 * time.Minute))
+				out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(out.RequeueAfter).To(BeNumerically(">", 0))
+
+				condition := conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+				Expect(condition.Reason).To(Equal(clusterv1.WaitingForControlPlaneAvailableReason))
+				Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityInfo))
+
+				machineContext.KubevirtMachine.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+				_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				condition = conditions.Get(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)
+				Expect(condition.Reason).To(Equal(infrav1.ControlPlaneInitializationStuckReason))
+				Expect(condition.Severity).To(Equal(clusterv1.ConditionSeverityWarning))
+			})
 			It("adds a failed VMProvisionedCondition with reason WaitingForBootstrapDataReason when bootstrap data is not yet available", func() {
 				machine.Spec.Bootstrap.DataSecretName = nil
 				delete(machine.ObjectMeta.Labels, clusterv1.MachineControlPlaneLabelName)
@@ -775,6 +2012,298 @@ var _ = Describe("reconcile a kubevirt machine", func() {
 				Expect(conditions[0].Reason).To(Equal(infrav1.BootstrapFailedReason))
 			})
 
+			It("recreates the VM with fresh user data when the bootstrap data is rotated before the VM bootstrapped", func() {
+				bootstrapUserDataSecret.Annotations = map[string]string{
+					bootstrapDataHashAnnotation: bootstrapDataHash([]byte("expired-token")),
+				}
+				bootstrapSecret.Data["value"] = []byte("fresh-token")
+
+				objects := []client.Object{
+					cluster,
+					kubevirtCluster,
+					machine,
+					kubevirtMachine,
+					bootstrapSecret,
+					bootstrapUserDataSecret,
+					sshKeySecret,
+				}
+
+				gomock.InOrder(
+					machineMock.EXPECT().Exists().Return(true),
+					machineMock.EXPECT().IsReady().Return(true),
+					machineMock.EXPECT().Address().Return("1.1.1.1"),
+					machineMock.EXPECT().SupportsCheckingIsBootstrapped().Return(true),
+					machineMock.EXPECT().IsBootstrapped().Return(false),
+					machineMock.EXPECT().Delete().Return(nil),
+				)
+				machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).Times(1)
+
+				setupClient(machineFactoryMock, objects)
+
+				infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).AnyTimes()
+
+				out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(out).To(Equal(ctrl.Result{RequeueAfter: 10 * time.Second}))
+				Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)).To(Equal(infrav1.BootstrapDataRotatedReason))
+				Expect(apierrors.IsNotFound(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(bootstrapUserDataSecret), &corev1.Secret{}))).To(BeTrue())
+
+				// the VM is recreated with user data generated from the rotated bootstrap data
+				gomock.InOrder(
+					machineMock.EXPECT().Exists().Return(false),
+					machineMock.EXPECT().Create(gomock.Any()).Return(nil),
+				)
+				machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).Times(1)
+
+				_, err = kubevirtMachineReconciler.reconcileNormal(machineContext)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				userDataSecret := &corev1.Secret{}
+				Expect(fakeClient.Get(gocontext.Background(), client.ObjectKeyFromObject(bootstrapUserDataSecret), userDataSecret)).To(Succeed())
+				Expect(string(userDataSecret.Data["userdata"])).To(ContainSubstring("fresh-token"))
+				Expect(userDataSecret.Annotations).To(HaveKeyWithValue(bootstrapDataHashAnnotation, bootstrapDataHash([]byte("fresh-token"))))
+			})
+
+			It("reports the bootstrap progress through the bootstrap markers", func() {
+				kubevirtCluster.Spec.BootstrapMarkers = []infrav1.BootstrapMarker{
+					{Name: "cloud-init done", Path: "/run/cloud-init.done"},
+					{Name: "containerd ready", Path: "/run/containerd.ready"},
+					{Name: "kubeadm joined", Path: "/run/kubeadm.joined"},
+				}
+				sshKeySecret.Data["pub"] = []byte("shell")
+
+				objects := []client.Object{
+					cluster,
+					kubevirtCluster,
+					machine,
+					kubevirtMachine,
+					bootstrapSecret,
+					bootstrapUserDataSecret,
+					sshKeySecret,
+				}
+
+				machineMock.EXPECT().Exists().Return(true).AnyTimes()
+				machineMock.EXPECT().IsReady().Return(true).AnyTimes()
+				machineMock.EXPECT().Address().Return("1.1.1.1").AnyTimes()
+				machineMock.EXPECT().SupportsCheckingIsBootstrapped().Return(true).AnyTimes()
+				machineMock.EXPECT().IsBootstrapped().Return(false).AnyTimes()
+				gomock.InOrder(
+					machineMock.EXPECT().BootstrapProgress().Return(0),
+					machineMock.EXPECT().BootstrapProgress().Return(1),
+					machineMock.EXPECT().BootstrapProgress().Return(3),
+				)
+
+				machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).AnyTimes()
+
+				setupClient(machineFactoryMock, objects)
+
+				infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).AnyTimes()
+
+				for _, expectedMessage := range []string{
+					"VM not bootstrapped yet, 0/4 stages completed (0%), waiting for cloud-init done",
+					"VM not bootstrapped yet, 1/4 stages completed (25%), waiting for containerd ready",
+					"VM not bootstrapped yet, 3/4 stages completed (75%), waiting for bootstrap completion",
+				} {
+					out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out).To(Equal(ctrl.Result{RequeueAfter: 10 * time.Second}))
+
+					condition := conditions.Get(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Reason).To(Equal(infrav1.BootstrapFailedReason))
+					Expect(condition.Message).To(Equal(expectedMessage))
+				}
+			})
+
+			It("reboots the VM once after the failed bootstrap checks of the bootstrap failure recovery", func() {
+				kubevirtMachine.Spec.BootstrapFailureRecovery = &infrav1.BootstrapFailureRecovery{RebootAfterFailedChecks: 2}
+				vmi.Status.Conditions = append(vmi.Status.Conditions, kubevirtv1.VirtualMachineInstanceCondition{
+					Type:   kubevirtv1.VirtualMachineInstanceReady,
+					Status: corev1.ConditionTrue,
+				})
+				sshKeySecret.Data["pub"] = []byte("shell")
+
+				objects := []client.Object{
+					cluster,
+					kubevirtCluster,
+					machine,
+					kubevirtMachine,
+					bootstrapSecret,
+					bootstrapUserDataSecret,
+					sshKeySecret,
+					vm,
+					vmi,
+				}
+
+				machineMock.EXPECT().Exists().Return(true).AnyTimes()
+				machineMock.EXPECT().IsReady().Return(true).AnyTimes()
+				machineMock.EXPECT().Address().Return("1.1.1.1").AnyTimes()
+				machineMock.EXPECT().SupportsCheckingIsBootstrapped().Return(true).AnyTimes()
+				machineMock.EXPECT().IsBootstrapped().Return(false).AnyTimes()
+				machineMock.EXPECT().Reboot().Return(nil).Times(1)
+
+				machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).AnyTimes()
+
+				setupClient(machineFactoryMock, objects)
+
+				infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil).AnyTimes()
+
+				reconcile := func() {
+					_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				reconcile()
+				Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)).To(Equal(infrav1.BootstrapFailedReason))
+				Expect(machineContext.KubevirtMachine.Status.BootstrapRebooted).To(BeFalse())
+
+				reconcile()
+				Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)).To(Equal(infrav1.BootstrapRebootedReason))
+				Expect(machineContext.KubevirtMachine.Status.BootstrapRebooted).To(BeTrue())
+
+				for i := 0; i < 3; i++ {
+					reconcile()
+				}
+				Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition)).To(Equal(infrav1.BootstrapFailedReason))
+				Expect(conditions.Get(machineContext.KubevirtMachine, infrav1.BootstrapExecSucceededCondition).Severity).To(Equal(clusterv1.ConditionSeverityError))
+			})
+
+			Context("guest agent policy", func() {
+				var objects []client.Object
+
+				BeforeEach(func() {
+					sshKeySecret.Data["pub"] = []byte("shell")
+					objects = []client.Object{
+						cluster,
+						kubevirtCluster,
+						machine,
+						kubevirtMachine,
+						bootstrapSecret,
+						bootstrapUserDataSecret,
+						sshKeySecret,
+					}
+
+					machineMock.EXPECT().Exists().Return(true).AnyTimes()
+					machineMock.EXPECT().IsReady().Return(true).AnyTimes()
+					machineMock.EXPECT().Address().Return("1.1.1.1").AnyTimes()
+					machineMock.EXPECT().SupportsCheckingIsBootstrapped().Return(false).AnyTimes()
+					machineMock.EXPECT().GenerateProviderID().Return("abc", nil).AnyTimes()
+					machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).AnyTimes()
+				})
+
+				It("adds a failed VMProvisionedCondition with reason AgentDisconnected when the required guest agent is not connected", func() {
+					kubevirtCluster.Spec.GuestAgentPolicy = infrav1.GuestAgentRequired
+					machineMock.EXPECT().IsAgentConnected().Return(false)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+					Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.AgentDisconnectedReason))
+				})
+
+				It("marks the machine ready when the required guest agent is connected", func() {
+					kubevirtCluster.Spec.GuestAgentPolicy = infrav1.GuestAgentRequired
+					machineMock.EXPECT().IsAgentConnected().Return(true)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+					Expect(conditions.IsTrue(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(BeTrue())
+				})
+
+				It("ignores the guest agent when it is optional", func() {
+					kubevirtCluster.Spec.GuestAgentPolicy = infrav1.GuestAgentOptional
+					machineMock.EXPECT().IsAgentConnected().Return(false).Times(0)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+					Expect(conditions.IsTrue(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(BeTrue())
+				})
+			})
+
+			Context("startup probe", func() {
+				var objects []client.Object
+
+				BeforeEach(func() {
+					sshKeySecret.Data["pub"] = []byte("shell")
+					kubevirtMachine.Spec.StartupProbe = &kubevirtv1.Probe{
+						Handler:          kubevirtv1.Handler{GuestAgentPing: &kubevirtv1.GuestAgentPing{}},
+						FailureThreshold: 60,
+					}
+					objects = []client.Object{
+						cluster,
+						kubevirtCluster,
+						machine,
+						kubevirtMachine,
+						bootstrapSecret,
+						bootstrapUserDataSecret,
+						sshKeySecret,
+					}
+
+					machineMock.EXPECT().Exists().Return(true).AnyTimes()
+					machineMock.EXPECT().IsReady().Return(true).AnyTimes()
+					machineMock.EXPECT().Address().Return("1.1.1.1").AnyTimes()
+					machineMock.EXPECT().SupportsCheckingIsBootstrapped().Return(false).AnyTimes()
+					machineMock.EXPECT().GenerateProviderID().Return("abc", nil).AnyTimes()
+					machineFactoryMock.EXPECT().NewMachine(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(machineMock, nil).AnyTimes()
+				})
+
+				AfterEach(func() {
+					kubevirtMachine.Spec.StartupProbe = nil
+				})
+
+				It("waits for the startup probe of the ready VM to succeed", func() {
+					machineMock.EXPECT().IsStarted().Return(false)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					out, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(out).To(Equal(ctrl.Result{RequeueAfter: 20 * time.Second}))
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeFalse())
+					Expect(machineContext.KubevirtMachine.Status.StartupProbeSucceeded).To(BeFalse())
+					Expect(conditions.GetReason(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(Equal(infrav1.WaitingForStartupProbeReason))
+				})
+
+				It("records the success of the startup probe and marks the machine ready", func() {
+					machineMock.EXPECT().IsStarted().Return(true)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+					Expect(machineContext.KubevirtMachine.Status.StartupProbeSucceeded).To(BeTrue())
+					Expect(conditions.IsTrue(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(BeTrue())
+				})
+
+				It("does not run the startup probe anymore once it succeeded", func() {
+					kubevirtMachine.Status.StartupProbeSucceeded = true
+					machineMock.EXPECT().IsStarted().Times(0)
+
+					setupClient(machineFactoryMock, objects)
+					infraClusterMock.EXPECT().GenerateInfraClusterClient(kubevirtMachine.Spec.InfraClusterSecretRef, kubevirtMachine.Namespace, machineContext.Context).Return(fakeClient, kubevirtMachine.Namespace, nil)
+
+					_, err := kubevirtMachineReconciler.reconcileNormal(machineContext)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(machineContext.KubevirtMachine.Status.Ready).To(BeTrue())
+					Expect(conditions.IsTrue(machineContext.KubevirtMachine, infrav1.VMProvisionedCondition)).To(BeTrue())
+				})
+			})
+
 			It("adds a succeeded BootstrapExecSucceededCondition", func() {
 				vmiReadyCondition := kubevirtv1.VirtualMachineInstanceCondition{
 					Type:   kubevirtv1.VirtualMachineInstanceReady,
@@ -939,6 +2468,76 @@ var _ = Describe("updateNodeProviderID", func() {
 		Expect(kubevirtMachine.Status.NodeUpdated).To(Equal(true))
 	})
 
+	It("should set providerID to the Node registered with the hostname of the VM", func() {
+		kubevirtMachine.Spec.ProviderID = &expectedProviderId
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Hostname = "worker"
+		kubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Subdomain = "nodes"
+		kubevirtCluster := testing.NewKubevirtCluster("kvcluster", "kvcluster")
+		kubevirtCluster.Spec.NodeMatchStrategy = infrav1.NodeMatchByHostname
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker.nodes.default.svc.cluster.local"},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeHostName, Address: "worker.nodes.default.svc.cluster.local"}},
+			},
+		}
+		Expect(fakeWorkloadClusterClient.Create(gocontext.Background(), node)).To(Succeed())
+
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.updateNodeProviderID(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Spec.ProviderID).To(Equal(expectedProviderId))
+		Expect(kubevirtMachine.Status.NodeUpdated).To(Equal(true))
+
+		// the node named after the machine is left alone
+		namedNode := &corev1.Node{}
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}, namedNode)).To(Succeed())
+		Expect(namedNode.Spec.ProviderID).To(BeEmpty())
+	})
+
+	It("should set providerID to the Node with the IP address of the VM", func() {
+		kubevirtMachine.Spec.ProviderID = &expectedProviderId
+		kubevirtMachine.Status.Addresses = []clusterv1.MachineAddress{{Type: clusterv1.MachineInternalIP, Address: "10.0.0.12"}}
+		kubevirtCluster := testing.NewKubevirtCluster("kvcluster", "kvcluster")
+		kubevirtCluster.Spec.NodeMatchStrategy = infrav1.NodeMatchByIP
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-10-0-0-12"},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.12"}},
+			},
+		}
+		Expect(fakeWorkloadClusterClient.Create(gocontext.Background(), node)).To(Succeed())
+
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.updateNodeProviderID(machineContext)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+		Expect(node.Spec.ProviderID).To(Equal(expectedProviderId))
+		Expect(kubevirtMachine.Status.NodeUpdated).To(Equal(true))
+	})
+
+	It("should not change the providerID of a Node owned by another machine", func() {
+		kubevirtMachine.Spec.ProviderID = &expectedProviderId
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, Logger: testLogger}
+		workloadClusterNode := &corev1.Node{}
+		workloadClusterNodeKey := client.ObjectKey{Namespace: kubevirtMachine.Namespace, Name: kubevirtMachine.Name}
+		Expect(fakeWorkloadClusterClient.Get(machineContext, workloadClusterNodeKey, workloadClusterNode)).To(Succeed())
+		workloadClusterNode.Spec.ProviderID = "kubevirt://another-machine"
+		Expect(fakeWorkloadClusterClient.Update(machineContext, workloadClusterNode)).To(Succeed())
+
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.updateNodeProviderID(machineContext)
+		Expect(err).Should(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+		Expect(fakeWorkloadClusterClient.Get(machineContext, workloadClusterNodeKey, workloadClusterNode)).To(Succeed())
+		Expect(workloadClusterNode.Spec.ProviderID).To(Equal("kubevirt://another-machine"))
+		Expect(kubevirtMachine.Status.NodeUpdated).To(Equal(false))
+	})
+
 	It("GenerateWorkloadClusterClient failure", func() {
 		kubevirtMachine.Spec.ProviderID = &expectedProviderId
 		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, Logger: testLogger}
@@ -970,6 +2569,229 @@ var _ = Describe("updateNodeProviderID", func() {
 	})
 })
 
+var _ = Describe("reconcileNodeLabels", func() {
+	var (
+		workloadClusterMock *workloadclustermock.MockWorkloadCluster
+		testLogger          = ctrl.Log.WithName("test")
+		workloadClusterNode *corev1.Node
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		workloadClusterMock = workloadclustermock.NewMockWorkloadCluster(mockCtrl)
+
+		kubevirtMachine = testing.NewKubevirtMachine("test-kubevirt-machine", "test-machine")
+		kubevirtCluster = testing.NewKubevirtCluster("kvcluster", "kvcluster")
+		kubevirtMachineReconciler = KubevirtMachineReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(kubevirtMachine).Build(),
+			WorkloadCluster: workloadClusterMock,
+		}
+
+		workloadClusterNode = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: kubevirtMachine.Name},
+		}
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(workloadClusterNode).Build()
+	})
+
+	reconcileNodeLabels := func() {
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.reconcileNodeLabels(machineContext)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+		Expect(fakeWorkloadClusterClient.Get(gocontext.Background(), client.ObjectKeyFromObject(workloadClusterNode), workloadClusterNode)).To(Succeed())
+	}
+
+	It("should not touch the Node without labels to set", func() {
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		out, err := kubevirtMachineReconciler.reconcileNodeLabels(machineContext)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{}))
+	})
+
+	It("should set instance-type label to Node when opted in", func() {
+		kubevirtMachine.Spec.NodeInstanceType = &infrav1.NodeInstanceType{Name: "small"}
+		reconcileNodeLabels()
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small"))
+	})
+
+	It("should set the cluster default labels to Node, the machine labels taking precedence", func() {
+		kubevirtMachine.Spec.NodeInstanceType = &infrav1.NodeInstanceType{Name: "small"}
+		kubevirtMachine.Spec.NodeLabels = map[string]string{"environment": "production"}
+		kubevirtCluster.Spec.DefaultNodeLabels = map[string]string{
+			"environment":                  "staging",
+			"team":                         "platform",
+			corev1.LabelInstanceTypeStable: "default",
+		}
+		reconcileNodeLabels()
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue("environment", "production"))
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue("team", "platform"))
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small"))
+
+		// the node is not patched again once labeled
+		resourceVersion := workloadClusterNode.ResourceVersion
+		reconcileNodeLabels()
+		Expect(workloadClusterNode.ResourceVersion).To(Equal(resourceVersion))
+	})
+
+	It("should set the labels again once the providerID is set on the Node", func() {
+		kubevirtMachine.Status.NodeUpdated = true
+		kubevirtCluster.Spec.DefaultNodeLabels = map[string]string{"environment": "staging"}
+		reconcileNodeLabels()
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue("environment", "staging"))
+
+		// a label removed from the node or changed on the cluster is applied again
+		delete(workloadClusterNode.Labels, "environment")
+		Expect(fakeWorkloadClusterClient.Update(gocontext.Background(), workloadClusterNode)).To(Succeed())
+		kubevirtCluster.Spec.DefaultNodeLabels["team"] = "platform"
+		reconcileNodeLabels()
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue("environment", "staging"))
+		Expect(workloadClusterNode.Labels).To(HaveKeyWithValue("team", "platform"))
+	})
+
+	It("should wait for the Node to appear", func() {
+		kubevirtCluster.Spec.DefaultNodeLabels = map[string]string{"environment": "staging"}
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.reconcileNodeLabels(machineContext)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(ctrl.Result{RequeueAfter: 10 * time.Second}))
+	})
+})
+
+var _ = Describe("reconcileNodeHealth", func() {
+	var (
+		workloadClusterMock *workloadclustermock.MockWorkloadCluster
+		testLogger          = ctrl.Log.WithName("test")
+		node                *corev1.Node
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		workloadClusterMock = workloadclustermock.NewMockWorkloadCluster(mockCtrl)
+
+		kubevirtMachine = testing.NewKubevirtMachine("test-kubevirt-machine", "test-machine")
+		kubevirtCluster = testing.NewKubevirtCluster("kvcluster", "kvcluster")
+		kubevirtMachine.Spec.NodeHealthMonitor = &infrav1.NodeHealthMonitor{
+			UnhealthyTimeout: metav1.Duration{Duration: 5 * time.Minute},
+			FailMachine:      true,
+		}
+		kubevirtMachineReconciler = KubevirtMachineReconciler{
+			Client:          fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(kubevirtMachine).Build(),
+			WorkloadCluster: workloadClusterMock,
+		}
+
+		node = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: kubevirtMachine.Name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse},
+				},
+			},
+		}
+	})
+
+	reconcileNodeHealth := func() ctrl.Result {
+		fakeWorkloadClusterClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(node).Build()
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+		workloadClusterMock.EXPECT().GenerateWorkloadClusterClient(machineContext).Return(fakeWorkloadClusterClient, nil)
+		out, err := kubevirtMachineReconciler.reconcileNodeHealth(machineContext)
+		Expect(err).NotTo(HaveOccurred())
+		return out
+	}
+
+	It("should report the machine unhealthy once the node is not ready past the unhealthy timeout", func() {
+		node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+
+		Expect(reconcileNodeHealth()).To(Equal(ctrl.Result{RequeueAfter: nodeHealthCheckInterval}))
+		Expect(conditions.IsFalse(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(infrav1.NodeUnhealthyReason))
+		Expect(*conditions.GetSeverity(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(clusterv1.ConditionSeverityWarning))
+		Expect(kubevirtMachine.Status.FailureReason).NotTo(BeNil())
+		Expect(kubevirtMachine.Status.FailureMessage).NotTo(BeNil())
+	})
+
+	It("should not report the machine unhealthy before the unhealthy timeout", func() {
+		node.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Minute))
+
+		out := reconcileNodeHealth()
+		Expect(out.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(out.RequeueAfter).To(BeNumerically("<=", nodeHealthCheckInterval))
+		Expect(conditions.IsFalse(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(infrav1.NodeNotReadyReason))
+		Expect(*conditions.GetSeverity(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(clusterv1.ConditionSeverityInfo))
+		Expect(kubevirtMachine.Status.FailureReason).To(BeNil())
+	})
+
+	It("should report the machine healthy once the node is ready", func() {
+		conditions.MarkFalse(kubevirtMachine, infrav1.NodeHealthyCondition, infrav1.NodeUnhealthyReason, clusterv1.ConditionSeverityWarning, "")
+		node.Status.Conditions[0].Status = corev1.ConditionTrue
+
+		Expect(reconcileNodeHealth()).To(Equal(ctrl.Result{RequeueAfter: nodeHealthCheckInterval}))
+		Expect(conditions.IsTrue(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+	})
+
+	It("should remove the condition without node health monitor nor node conditions reported", func() {
+		conditions.MarkTrue(kubevirtMachine, infrav1.NodeHealthyCondition)
+		kubevirtMachine.Spec.NodeHealthMonitor = nil
+		machineContext := &context.MachineContext{KubevirtMachine: kubevirtMachine, KubevirtCluster: kubevirtCluster, Logger: testLogger}
+
+		Expect(kubevirtMachineReconciler.reconcileNodeHealth(machineContext)).To(Equal(ctrl.Result{}))
+		Expect(conditions.Has(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeFalse())
+	})
+
+	Context("with node conditions reported", func() {
+		BeforeEach(func() {
+			kubevirtCluster.Spec.ReportNodeConditions = true
+			kubevirtMachine.Spec.NodeHealthMonitor = nil
+		})
+
+		It("should report a missing node", func() {
+			node.Name = "another-node"
+
+			Expect(reconcileNodeHealth()).To(Equal(ctrl.Result{RequeueAfter: nodeHealthCheckInterval}))
+			Expect(conditions.IsFalse(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+			Expect(conditions.GetReason(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(infrav1.NodeNotFoundReason))
+		})
+
+		It("should report a node not ready", func() {
+			Expect(reconcileNodeHealth()).To(Equal(ctrl.Result{RequeueAfter: nodeHealthCheckInterval}))
+			Expect(conditions.IsFalse(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+			Expect(conditions.GetReason(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(infrav1.NodeNotReadyReason))
+			Expect(*conditions.GetSeverity(kubevirtMachine, infrav1.NodeHealthyCondition)).To(Equal(clusterv1.ConditionSeverityWarning))
+		})
+
+		It("should report a ready node healthy", func() {
+			node.Status.Conditions[0].Status = corev1.ConditionTrue
+
+			Expect(reconcileNodeHealth()).To(Equal(ctrl.Result{RequeueAfter: nodeHealthCheckInterval}))
+			Expect(conditions.IsTrue(kubevirtMachine, infrav1.NodeHealthyCondition)).To(BeTrue())
+		})
+	})
+})
+
+// throttledClient is an infra cluster client reporting client-side throttling.
+type throttledClient struct {
+	client.Client
+}
+
+func (c *throttledClient) IsThrottled() bool {
+	return true
+}
+
+// flavorForbiddenClient is an infra cluster client whose credentials are not allowed to read the cluster flavors.
+type flavorForbiddenClient struct {
+	client.Client
+}
+
+func (c *flavorForbiddenClient) Get(ctx gocontext.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*flavorv1alpha1.VirtualMachineClusterFlavor); ok {
+		return apierrors.NewForbidden(flavorv1alpha1.Resource("virtualmachineclusterflavors"), key.Name, errors.New("access denied"))
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
 func setupScheme() *runtime.Scheme {
 	s := runtime.NewScheme()
 	if err := clusterv1.AddToScheme(s); err != nil {
@@ -984,5 +2806,17 @@ func setupScheme() *runtime.Scheme {
 	if err := corev1.AddToScheme(s); err != nil {
 		panic(err)
 	}
+	if err := storagev1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := schedulingv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := flavorv1alpha1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := cdiv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
 	return s
 }
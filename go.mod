@@ -17,9 +17,11 @@ require (
 	k8s.io/component-base v0.23.0-alpha.4
 	k8s.io/klog/v2 v2.30.0
 	kubevirt.io/api v0.0.0-20211117075245-c94ce62baf5a
+	kubevirt.io/containerized-data-importer-api v1.41.0
 	sigs.k8s.io/cluster-api v0.3.11-0.20210525210043-6c7878e7b4a9
 	sigs.k8s.io/controller-runtime v0.11.0-beta.0.0.20211110210527-619e6b92dab9
 	sigs.k8s.io/kind v0.11.0
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
@@ -69,11 +71,9 @@ require (
 	k8s.io/apiextensions-apiserver v0.23.0-alpha.4 // indirect
 	k8s.io/kube-openapi v0.0.0-20210817084001-7fbd8d59e5b8 // indirect
 	k8s.io/utils v0.0.0-20210930125809-cb0fa318a74b // indirect
-	kubevirt.io/containerized-data-importer-api v1.41.0 // indirect
 	kubevirt.io/controller-lifecycle-operator-sdk v0.2.1 // indirect
 	sigs.k8s.io/json v0.0.0-20211020170558-c049b76a60c6 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.2 // indirect
-	sigs.k8s.io/yaml v1.3.0 // indirect
 )
 
 replace (
@@ -28,6 +28,8 @@ import (
 	"sigs.k8s.io/cluster-api-provider-kubevirt/controllers"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/infracluster"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/kubevirt"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/notification"
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/semaphore"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/workloadcluster"
 
 	"github.com/spf13/pflag"
@@ -38,6 +40,8 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	flavorv1alpha1 "kubevirt.io/api/flavor/v1alpha1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/feature"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -53,14 +57,18 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 
 	//flags.
-	metricsBindAddr      string
-	enableLeaderElection bool
-	syncPeriod           time.Duration
-	concurrency          int
-	healthAddr           string
-	webhookPort          int
-	webhookCertDir       string
-	watchNamespace       string
+	metricsBindAddr       string
+	enableLeaderElection  bool
+	syncPeriod            time.Duration
+	concurrency           int
+	healthAddr            string
+	webhookPort           int
+	webhookCertDir        string
+	watchNamespace        string
+	infraClusterQPS       float32
+	infraClusterBurst     int
+	maxVMCreations        int
+	vmProvisioningTimeout time.Duration
 )
 
 func init() {
@@ -70,6 +78,8 @@ func init() {
 	_ = infrav1.AddToScheme(myscheme)
 	_ = clusterv1.AddToScheme(myscheme)
 	_ = kubevirtv1.AddToScheme(myscheme)
+	_ = cdiv1.AddToScheme(myscheme)
+	_ = flavorv1alpha1.AddToScheme(myscheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -91,6 +101,15 @@ func initFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&watchNamespace, "namespace", "",
 		"Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
 
+	fs.Float32Var(&infraClusterQPS, "infra-cluster-qps", 0,
+		"Maximum queries per second from the controller to an external infra cluster, shared by all its clients. If unspecified, the client-go default is used.")
+	fs.IntVar(&infraClusterBurst, "infra-cluster-burst", 0,
+		"Maximum burst of queries from the controller to an external infra cluster, shared by all its clients. If unspecified, the client-go default is used.")
+	fs.IntVar(&maxVMCreations, "max-concurrent-vm-creations", 0,
+		"Maximum number of VMs being provisioned at once across all clusters, from their creation until they are ready. Zero means no limit.")
+	fs.DurationVar(&vmProvisioningTimeout, "vm-provisioning-timeout", 30*time.Minute,
+		"Time a VM which does not become ready counts against --max-concurrent-vm-creations. Zero means no timeout.")
+
 	feature.MutableGates.AddFlag(fs)
 }
 
@@ -118,6 +137,7 @@ func main() {
 		Port:                   webhookPort,
 		CertDir:                webhookCertDir,
 		Namespace:              watchNamespace,
+		NewClient:              infracluster.NewThrottleReportingClient,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -152,11 +172,17 @@ func setupChecks(mgr ctrl.Manager) {
 }
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
+	infraCluster := infracluster.New(mgr.GetClient(), infraClusterQPS, infraClusterBurst)
+
 	if err := (&controllers.KubevirtMachineReconciler{
-		Client:          mgr.GetClient(),
-		InfraCluster:    infracluster.New(mgr.GetClient()),
-		WorkloadCluster: workloadcluster.New(mgr.GetClient()),
-		MachineFactory:  kubevirt.DefaultMachineFactory{},
+		Client:                mgr.GetClient(),
+		InfraCluster:          infraCluster,
+		WorkloadCluster:       workloadcluster.New(mgr.GetClient()),
+		MachineFactory:        kubevirt.DefaultMachineFactory{},
+		Recorder:              mgr.GetEventRecorderFor("kubevirtmachine-controller"),
+		Notifier:              notification.New(ctrl.Log.WithName("notification")),
+		VMCreations:           semaphore.New(maxVMCreations),
+		VMProvisioningTimeout: vmProvisioningTimeout,
 	}).SetupWithManager(ctx, mgr, controller.Options{
 		MaxConcurrentReconciles: concurrency,
 	}); err != nil {
@@ -165,7 +191,7 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	}
 	if err := (&controllers.KubevirtClusterReconciler{
 		Client:       mgr.GetClient(),
-		InfraCluster: infracluster.New(mgr.GetClient()),
+		InfraCluster: infraCluster,
 		Log:          ctrl.Log.WithName("controllers").WithName("KubevirtCluster"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KubevirtCluster")
@@ -178,4 +204,8 @@ func setupWebhooks(mgr ctrl.Manager) {
 		setupLog.Error(err, "unable to create webhook", "webhook", "KubevirtMachineTemplate")
 		os.Exit(1)
 	}
+	if err := (&infrav1.KubevirtCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "KubevirtCluster")
+		os.Exit(1)
+	}
 }
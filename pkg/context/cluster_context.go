@@ -41,6 +41,15 @@ func (c *ClusterContext) String() string {
 	return fmt.Sprintf("%s %s/%s", c.KubevirtCluster.GroupVersionKind(), c.KubevirtCluster.Namespace, c.KubevirtCluster.Name)
 }
 
+// InfraResourceName returns the name of an object created in the infra cluster for this cluster,
+// prefixed with the InfraResourceNamePrefix of the KubevirtCluster.
+func (c *ClusterContext) InfraResourceName(name string) string {
+	if c.KubevirtCluster == nil || c.KubevirtCluster.Spec.InfraResourceNamePrefix == "" {
+		return name
+	}
+	return c.KubevirtCluster.Spec.InfraResourceNamePrefix + "-" + name
+}
+
 // PatchKubevirtCluster patches the KubevirtCluster object and status.
 func (c *ClusterContext) PatchKubevirtCluster(patchHelper *patch.Helper) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
@@ -57,6 +57,46 @@ func (c *MachineContext) String() string {
 	return fmt.Sprintf("%s %s/%s", c.KubevirtMachine.GroupVersionKind(), c.KubevirtMachine.Namespace, c.KubevirtMachine.Name)
 }
 
+// VMName returns the name of the VM (and VMI) hosting this machine in the infra cluster.
+func (c *MachineContext) VMName() string {
+	return c.infraResourceName(c.KubevirtMachine.Name)
+}
+
+// UserDataSecretName returns the name of the secret holding the userdata of this machine in the infra cluster.
+// It must only be called once the machine's bootstrap data secret name is set.
+func (c *MachineContext) UserDataSecretName() string {
+	return c.infraResourceName(*c.Machine.Spec.Bootstrap.DataSecretName + "-userdata")
+}
+
+// RecordInfraResourceNamePrefix records the infra resource name prefix of the KubevirtCluster in the status of the
+// machine, unless it is already recorded.
+func (c *MachineContext) RecordInfraResourceNamePrefix() {
+	if c.KubevirtMachine.Status.InfraResourceNamePrefix != nil || c.KubevirtCluster == nil {
+		return
+	}
+	prefix := c.KubevirtCluster.Spec.InfraResourceNamePrefix
+	c.KubevirtMachine.Status.InfraResourceNamePrefix = &prefix
+}
+
+// HasInfraResourceNames checks if the names of the objects of this machine in the infra cluster are known, i.e.
+// the infra resource name prefix is recorded in the status of the machine or the KubevirtCluster is available.
+func (c *MachineContext) HasInfraResourceNames() bool {
+	return c.KubevirtMachine.Status.InfraResourceNamePrefix != nil || c.KubevirtCluster != nil
+}
+
+// infraResourceName returns the name of an object created in the infra cluster for this machine, prefixed with the
+// infra resource name prefix recorded in the status of the machine, or else with the one of the KubevirtCluster.
+func (c *MachineContext) infraResourceName(name string) string {
+	prefix := c.KubevirtMachine.Status.InfraResourceNamePrefix
+	if prefix == nil {
+		return c.ClusterContext().InfraResourceName(name)
+	}
+	if *prefix == "" {
+		return name
+	}
+	return *prefix + "-" + name
+}
+
 // PatchKubevirtMachine patches the KubevirtMachine object and status.
 func (c *MachineContext) PatchKubevirtMachine(patchHelper *patch.Helper) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
@@ -77,6 +117,8 @@ func (c *MachineContext) PatchKubevirtMachine(patchHelper *patch.Helper) error {
 			clusterv1.ReadyCondition,
 			infrav1.VMProvisionedCondition,
 			infrav1.BootstrapExecSucceededCondition,
+			infrav1.ThrottledByInfraAPICondition,
+			infrav1.NodeHealthyCondition,
 		}},
 	)
 }
@@ -3,9 +3,11 @@ package infracluster
 import (
 	gocontext "context"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -13,20 +15,33 @@ import (
 //go:generate mockgen -source=./infracluster.go -destination=./mock/infracluster_generated.go -package=mock
 type InfraCluster interface {
 	GenerateInfraClusterClient(infraClusterSecretRef *corev1.ObjectReference, ownerNamespace string, context gocontext.Context) (client.Client, string, error)
+	ReleaseInfraCluster(infraClusterSecretRef *corev1.ObjectReference)
 }
 
-// New creates new InfraCluster instance
-func New(client client.Client) InfraCluster {
+// New creates new InfraCluster instance. The clients of an infra cluster share a client-side rate limiter allowing
+// qps requests per second, with bursts of burst requests, which falls back to the client-go defaults when unset,
+// so that the throttling of the infra cluster is detected across reconciles.
+func New(client client.Client, qps float32, burst int) InfraCluster {
 	return &infraCluster{
-		Client: client,
+		Client:       client,
+		qps:          qps,
+		burst:        burst,
+		rateLimiters: map[string]*throttleDetectingRateLimiter{},
 	}
 }
 
 type infraCluster struct {
 	client.Client
+
+	qps   float32
+	burst int
+
+	lock         sync.Mutex
+	rateLimiters map[string]*throttleDetectingRateLimiter
 }
 
-// GenerateInfraClusterClient creates a client for infra cluster.
+// GenerateInfraClusterClient creates a client for infra cluster. Without infra cluster kubeconfig, the client of the
+// management cluster is returned, which only reports throttling when created by NewThrottleReportingClient.
 func (w *infraCluster) GenerateInfraClusterClient(infraClusterSecretRef *corev1.ObjectReference, ownerNamespace string, context gocontext.Context) (client.Client, string, error) {
 	if infraClusterSecretRef == nil {
 		return w.Client, ownerNamespace, nil
@@ -56,11 +71,52 @@ func (w *infraCluster) GenerateInfraClusterClient(infraClusterSecretRef *corev1.
 		return nil, "", errors.Wrap(err, "failed to create REST config")
 	}
 
+	rateLimiter := w.rateLimiter(infraClusterSecretRef)
+	restConfig.RateLimiter = rateLimiter
+
 	// create the client
 	infraClusterClient, err := client.New(restConfig, client.Options{Scheme: w.Client.Scheme()})
 	if err != nil {
 		return nil, "", errors.Wrap(err, "failed to create infra cluster client")
 	}
 
-	return infraClusterClient, namespace, nil
+	return &throttleReportingClient{Client: infraClusterClient, rateLimiter: rateLimiter}, namespace, nil
+}
+
+// ReleaseInfraCluster drops the rate limiter shared by the clients of the infra cluster of the given
+// kubeconfig secret, once no KubevirtCluster uses that infra cluster anymore.
+func (w *infraCluster) ReleaseInfraCluster(infraClusterSecretRef *corev1.ObjectReference) {
+	if infraClusterSecretRef == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.rateLimiters, rateLimiterKey(infraClusterSecretRef))
+}
+
+// rateLimiter returns the rate limiter shared by the clients of the infra cluster of the given kubeconfig secret.
+func (w *infraCluster) rateLimiter(infraClusterSecretRef *corev1.ObjectReference) *throttleDetectingRateLimiter {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	key := rateLimiterKey(infraClusterSecretRef)
+	rateLimiter, ok := w.rateLimiters[key]
+	if !ok {
+		qps, burst := w.qps, w.burst
+		if qps <= 0 {
+			qps = rest.DefaultQPS
+		}
+		if burst <= 0 {
+			burst = rest.DefaultBurst
+		}
+		rateLimiter = newThrottleDetectingRateLimiter(qps, burst)
+		w.rateLimiters[key] = rateLimiter
+	}
+	return rateLimiter
+}
+
+func rateLimiterKey(infraClusterSecretRef *corev1.ObjectReference) string {
+	return infraClusterSecretRef.Namespace + "/" + infraClusterSecretRef.Name
 }
@@ -0,0 +1,13 @@
+package infracluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInfraCluster(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "InfraCluster Suite")
+}
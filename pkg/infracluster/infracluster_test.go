@@ -0,0 +1,76 @@
+package infracluster
+
+import (
+	gocontext "context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("InfraCluster", func() {
+	secretRef := &corev1.ObjectReference{Namespace: "default", Name: "infra-kubeconfig"}
+
+	It("should share the rate limiter of an infra cluster between its clients", func() {
+		w := New(fake.NewClientBuilder().Build(), 0, 0).(*infraCluster)
+
+		rateLimiter := w.rateLimiter(secretRef)
+		Expect(w.rateLimiter(secretRef.DeepCopy())).To(BeIdenticalTo(rateLimiter))
+		Expect(w.rateLimiter(&corev1.ObjectReference{Namespace: "default", Name: "other-kubeconfig"})).NotTo(BeIdenticalTo(rateLimiter))
+
+		// the throttling of the infra cluster outlives the clients created by a reconcile
+		rateLimiter.observe(2 * throttleLatencyThreshold)
+		Expect(w.rateLimiter(secretRef).IsThrottled()).To(BeTrue())
+	})
+
+	It("should use the client-go default rate limit when no rate limit is configured", func() {
+		w := New(fake.NewClientBuilder().Build(), 0, 0).(*infraCluster)
+		Expect(w.rateLimiter(secretRef).QPS()).To(Equal(rest.DefaultQPS))
+	})
+
+	It("should use the configured rate limit", func() {
+		w := New(fake.NewClientBuilder().Build(), 50, 100).(*infraCluster)
+		Expect(w.rateLimiter(secretRef).QPS()).To(Equal(float32(50)))
+	})
+
+	It("should release the rate limiter of an infra cluster", func() {
+		w := New(fake.NewClientBuilder().Build(), 0, 0).(*infraCluster)
+
+		rateLimiter := w.rateLimiter(secretRef)
+		w.ReleaseInfraCluster(secretRef.DeepCopy())
+		Expect(w.rateLimiters).To(BeEmpty())
+		Expect(w.rateLimiter(secretRef)).NotTo(BeIdenticalTo(rateLimiter))
+
+		// machines without infra cluster kubeconfig have no rate limiter to release
+		w.ReleaseInfraCluster(nil)
+		Expect(w.rateLimiters).To(HaveLen(1))
+	})
+
+	It("should return the management cluster client, reporting its throttling, without infra cluster kubeconfig", func() {
+		rateLimiter := newThrottleDetectingRateLimiter(10, 10)
+		managementClusterClient := &throttleReportingClient{Client: fake.NewClientBuilder().Build(), rateLimiter: rateLimiter}
+		w := New(managementClusterClient, 0, 0)
+
+		infraClusterClient, namespace, err := w.GenerateInfraClusterClient(nil, "cluster-namespace", gocontext.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(infraClusterClient).To(BeIdenticalTo(managementClusterClient))
+		Expect(namespace).To(Equal("cluster-namespace"))
+
+		rateLimiter.observe(2 * throttleLatencyThreshold)
+		Expect(IsThrottled(infraClusterClient)).To(BeTrue())
+	})
+
+	It("should fail without the kubeconfig of the infra cluster", func() {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: secretRef.Namespace, Name: secretRef.Name}}
+		w := New(fake.NewClientBuilder().WithObjects(secret).Build(), 0, 0)
+
+		_, _, err := w.GenerateInfraClusterClient(secretRef, "cluster-namespace", gocontext.TODO())
+		Expect(err).To(MatchError(ContainSubstring("'kubeconfig' key is missing")))
+
+		_, _, err = w.GenerateInfraClusterClient(&corev1.ObjectReference{Namespace: "default", Name: "missing"}, "cluster-namespace", gocontext.TODO())
+		Expect(err).To(MatchError(ContainSubstring("failed to fetch infra kubeconfig secret")))
+	})
+})
@@ -51,3 +51,15 @@ func (mr *MockInfraClusterMockRecorder) GenerateInfraClusterClient(infraClusterS
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateInfraClusterClient", reflect.TypeOf((*MockInfraCluster)(nil).GenerateInfraClusterClient), infraClusterSecretRef, ownerNamespace, context)
 }
+
+// ReleaseInfraCluster mocks base method.
+func (m *MockInfraCluster) ReleaseInfraCluster(infraClusterSecretRef *v1.ObjectReference) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReleaseInfraCluster", infraClusterSecretRef)
+}
+
+// ReleaseInfraCluster indicates an expected call of ReleaseInfraCluster.
+func (mr *MockInfraClusterMockRecorder) ReleaseInfraCluster(infraClusterSecretRef interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseInfraCluster", reflect.TypeOf((*MockInfraCluster)(nil).ReleaseInfraCluster), infraClusterSecretRef)
+}
@@ -0,0 +1,112 @@
+package infracluster
+
+import (
+	gocontext "context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+const (
+	// throttleLatencyThreshold is the time a request waits for the client-side rate limiter above which
+	// the request is considered throttled.
+	throttleLatencyThreshold = time.Second
+
+	// throttledPeriod is the time a client is reported as throttled after its last throttled request.
+	throttledPeriod = time.Minute
+)
+
+// ThrottleReporter is implemented by infra cluster clients detecting client-side rate limiting.
+type ThrottleReporter interface {
+	// IsThrottled returns true when requests were recently delayed by the client-side rate limiter.
+	IsThrottled() bool
+}
+
+// IsThrottled checks if requests of the infra cluster client were recently delayed by its client-side rate limiter.
+func IsThrottled(c client.Client) bool {
+	reporter, ok := c.(ThrottleReporter)
+	return ok && reporter.IsThrottled()
+}
+
+// NewThrottleReportingClient creates a client whose requests share a rate limiter detecting client-side throttling,
+// with the QPS and burst of the REST config. It is meant as the NewClient of the manager, whose client is the infra
+// cluster client of the machines without infra cluster kubeconfig.
+func NewThrottleReportingClient(cache cache.Cache, config *rest.Config, options client.Options, uncachedObjects ...client.Object) (client.Client, error) {
+	qps, burst := config.QPS, config.Burst
+	if qps <= 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst <= 0 {
+		burst = rest.DefaultBurst
+	}
+	rateLimiter := newThrottleDetectingRateLimiter(qps, burst)
+
+	config = rest.CopyConfig(config)
+	config.RateLimiter = rateLimiter
+	c, err := cluster.DefaultNewClient(cache, config, options, uncachedObjects...)
+	if err != nil {
+		return nil, err
+	}
+	return &throttleReportingClient{Client: c, rateLimiter: rateLimiter}, nil
+}
+
+// throttleDetectingRateLimiter is a rate limiter recording when requests had to wait for a token.
+type throttleDetectingRateLimiter struct {
+	flowcontrol.RateLimiter
+
+	lock          sync.Mutex
+	lastThrottled time.Time
+}
+
+func newThrottleDetectingRateLimiter(qps float32, burst int) *throttleDetectingRateLimiter {
+	return &throttleDetectingRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+	}
+}
+
+// Accept implements flowcontrol.RateLimiter.
+func (l *throttleDetectingRateLimiter) Accept() {
+	start := time.Now()
+	l.RateLimiter.Accept()
+	l.observe(time.Since(start))
+}
+
+// Wait implements flowcontrol.RateLimiter.
+func (l *throttleDetectingRateLimiter) Wait(ctx gocontext.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	l.observe(time.Since(start))
+	return err
+}
+
+func (l *throttleDetectingRateLimiter) observe(latency time.Duration) {
+	if latency < throttleLatencyThreshold {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lastThrottled = time.Now()
+}
+
+// IsThrottled implements ThrottleReporter.
+func (l *throttleDetectingRateLimiter) IsThrottled() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return !l.lastThrottled.IsZero() && time.Since(l.lastThrottled) < throttledPeriod
+}
+
+// throttleReportingClient is an infra cluster client reporting the throttling of its rate limiter.
+type throttleReportingClient struct {
+	client.Client
+	rateLimiter *throttleDetectingRateLimiter
+}
+
+// IsThrottled implements ThrottleReporter.
+func (c *throttleReportingClient) IsThrottled() bool {
+	return c.rateLimiter.IsThrottled()
+}
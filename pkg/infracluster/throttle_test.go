@@ -0,0 +1,49 @@
+package infracluster
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Throttle detection", func() {
+	var rateLimiter *throttleDetectingRateLimiter
+
+	BeforeEach(func() {
+		rateLimiter = newThrottleDetectingRateLimiter(10, 10)
+	})
+
+	It("should not report throttling without delayed requests", func() {
+		rateLimiter.Accept()
+		Expect(rateLimiter.IsThrottled()).To(BeFalse())
+	})
+
+	It("should not report requests waiting shortly for the rate limiter as throttled", func() {
+		rateLimiter.observe(throttleLatencyThreshold / 2)
+		Expect(rateLimiter.IsThrottled()).To(BeFalse())
+	})
+
+	It("should report requests waiting for the rate limiter as throttled", func() {
+		rateLimiter.observe(2 * throttleLatencyThreshold)
+		Expect(rateLimiter.IsThrottled()).To(BeTrue())
+	})
+
+	It("should stop reporting throttling after the throttled period", func() {
+		rateLimiter.lastThrottled = time.Now().Add(-throttledPeriod - time.Second)
+		Expect(rateLimiter.IsThrottled()).To(BeFalse())
+	})
+
+	It("should report the throttling of the rate limiter of a client", func() {
+		c := &throttleReportingClient{Client: fake.NewClientBuilder().Build(), rateLimiter: rateLimiter}
+		Expect(IsThrottled(c)).To(BeFalse())
+
+		rateLimiter.observe(2 * throttleLatencyThreshold)
+		Expect(IsThrottled(c)).To(BeTrue())
+	})
+
+	It("should not report clients without rate limiter as throttled", func() {
+		Expect(IsThrottled(fake.NewClientBuilder().Build())).To(BeFalse())
+	})
+})
@@ -18,7 +18,13 @@ package kubevirt
 
 import (
 	gocontext "context"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -34,6 +40,9 @@ import (
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/ssh"
 )
 
+// maxStartupProbeTimeout bounds the time a startup probe of a VM may take within a reconcile.
+const maxStartupProbeTimeout = 3 * time.Second
+
 // Machine implement a service for managing the KubeVirt VM hosting a kubernetes node.
 type Machine struct {
 	client         client.Client
@@ -58,7 +67,7 @@ func NewMachine(ctx *context.MachineContext, client client.Client, namespace str
 		getCommandExecutor: ssh.NewVMCommandExecutor,
 	}
 
-	namespacedName := types.NamespacedName{Namespace: namespace, Name: ctx.KubevirtMachine.Name}
+	namespacedName := types.NamespacedName{Namespace: namespace, Name: ctx.VMName()}
 	vm := &kubevirtv1.VirtualMachine{}
 	vmi := &kubevirtv1.VirtualMachineInstance{}
 
@@ -94,8 +103,17 @@ func (m *Machine) Exists() bool {
 func (m *Machine) Create(ctx gocontext.Context) error {
 	m.machineContext.Logger.Info(fmt.Sprintf("Creating VM with role '%s'...", nodeRole(m.machineContext)))
 
+	// The root boot source is required by the KubevirtMachineTemplate webhook, only ambiguous VMs are rejected here.
+	if err := m.machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.ValidateRootBootSource(); err != nil && !errors.Is(err, infrav1.ErrNoRootBootSource) {
+		return err
+	}
+
 	virtualMachine := newVirtualMachineFromKubevirtMachine(m.machineContext, m.namespace)
 
+	if err := applyStorageProfileDefaults(ctx, m.client, virtualMachine); err != nil {
+		return err
+	}
+
 	mutateFn := func() (err error) {
 		if virtualMachine.Labels == nil {
 			virtualMachine.Labels = map[string]string{}
@@ -145,11 +163,64 @@ func (m *Machine) Address() string {
 	return ""
 }
 
+// sshAddress returns the address the VM is reached at over SSH, i.e. the IP of its virt-launcher pod in the PodIP
+// SSH address mode, and the address of the VM otherwise.
+func (m *Machine) sshAddress() string {
+	if m.machineContext.KubevirtCluster == nil || m.machineContext.KubevirtCluster.Spec.SSHAddressMode != infrav1.SSHAddressPodIP {
+		return m.Address()
+	}
+	if m.vmiInstance == nil {
+		return ""
+	}
+
+	pods := &corev1.PodList{}
+	if err := m.client.List(m.machineContext.Context, pods, client.InNamespace(m.namespace),
+		client.MatchingLabels{kubevirtv1.CreatedByLabel: string(m.vmiInstance.UID)}); err != nil {
+		m.machineContext.Logger.Error(err, "failed to list the virt-launcher pods of the VM")
+		return ""
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			return pod.Status.PodIP
+		}
+	}
+	return ""
+}
+
 // IsReady checks if the VM is ready
 func (m *Machine) IsReady() bool {
 	return m.hasReadyCondition()
 }
 
+// IsAgentConnected checks if the guest agent of the VM is connected.
+func (m *Machine) IsAgentConnected() bool {
+	if m.vmiInstance == nil {
+		return false
+	}
+
+	for _, cond := range m.vmiInstance.Status.Conditions {
+		if cond.Type == kubevirtv1.VirtualMachineInstanceAgentConnected &&
+			cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reboot reboots the guest by deleting the VMI of the VM, which KubeVirt recreates for a running VM.
+func (m *Machine) Reboot() error {
+	if m.vmiInstance == nil {
+		return nil
+	}
+
+	if err := m.client.Delete(m.machineContext.Context, m.vmiInstance); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete VMI")
+	}
+
+	return nil
+}
+
 // SupportsCheckingIsBootstrapped checks if we have a method of checking
 // that this bootstrapper has completed.
 func (m *Machine) SupportsCheckingIsBootstrapped() bool {
@@ -169,7 +240,7 @@ func (m *Machine) IsBootstrapped() bool {
 		return false
 	}
 
-	executor := m.getCommandExecutor(m.Address(), m.sshKeys)
+	executor := m.getCommandExecutor(m.sshAddress(), m.sshKeys)
 
 	output, err := executor.ExecuteCommand("cat /run/cluster-api/bootstrap-success.complete")
 	if err != nil || output != "success" {
@@ -178,6 +249,133 @@ func (m *Machine) IsBootstrapped() bool {
 	return true
 }
 
+// IsStarted checks if the startup probe of the machine succeeds against the VM. The tcpSocket and httpGet handlers
+// reach the VM at the address used to check the bootstrap.
+func (m *Machine) IsStarted() bool {
+	probe := m.machineContext.KubevirtMachine.Spec.StartupProbe
+	if probe == nil {
+		return true
+	}
+	if !m.IsReady() {
+		return false
+	}
+	if probe.GuestAgentPing != nil {
+		return m.IsAgentConnected()
+	}
+
+	address := m.sshAddress()
+	if address == "" {
+		return false
+	}
+	// The probe runs within the reconcile, so an unreachable VM must not hold the worker for long. The machine is
+	// requeued and probed again anyway while it did not start.
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = time.Second
+	}
+	if timeout > maxStartupProbeTimeout {
+		timeout = maxStartupProbeTimeout
+	}
+	ctx, cancel := gocontext.WithTimeout(m.machineContext.Context, timeout)
+	defer cancel()
+
+	switch {
+	case probe.TCPSocket != nil:
+		return probeTCPSocket(ctx, probe.TCPSocket, address)
+	case probe.HTTPGet != nil:
+		return probeHTTPGet(ctx, probe.HTTPGet, address)
+	default:
+		return false
+	}
+}
+
+// probeTCPSocket checks if a TCP connection can be opened to the port of the VM, like the kubelet does.
+func probeTCPSocket(ctx gocontext.Context, action *corev1.TCPSocketAction, address string) bool {
+	host := action.Host
+	if host == "" {
+		host = address
+	}
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, action.Port.String()))
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// probeHTTPGet checks if an HTTP GET request to the VM succeeds, like the kubelet does: the probe succeeds for a
+// status code in the 2xx and 3xx ranges, and the certificate of an HTTPS server is not verified.
+func probeHTTPGet(ctx gocontext.Context, action *corev1.HTTPGetAction, address string) bool {
+	host := action.Host
+	if host == "" {
+		host = address
+	}
+	scheme := strings.ToLower(string(action.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	probeURL := &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, action.Port.String()),
+		Path:   action.Path,
+	}
+	if i := strings.Index(action.Path, "?"); i >= 0 {
+		probeURL.Path, probeURL.RawQuery = action.Path[:i], action.Path[i+1:]
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return false
+	}
+	for _, header := range action.HTTPHeaders {
+		if strings.EqualFold(header.Name, "Host") {
+			request.Host = header.Value
+			continue
+		}
+		request.Header.Add(header.Name, header.Value)
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return false
+	}
+	defer response.Body.Close()
+	return response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusBadRequest
+}
+
+// BootstrapProgress returns the number of bootstrap markers of the cluster reached by the VM.
+// The markers are checked in sequence, stopping at the first marker not reached yet, in a single SSH session.
+func (m *Machine) BootstrapProgress() int {
+	markers := m.machineContext.KubevirtCluster.Spec.BootstrapMarkers
+	if !m.IsReady() || m.sshKeys == nil || len(markers) == 0 {
+		return 0
+	}
+
+	executor := m.getCommandExecutor(m.sshAddress(), m.sshKeys)
+
+	paths := make([]string, 0, len(markers))
+	for _, marker := range markers {
+		paths = append(paths, shellQuote(marker.Path))
+	}
+	output, err := executor.ExecuteCommand(fmt.Sprintf(`for marker in %s; do test -e "$marker" || break; echo reached; done`, strings.Join(paths, " ")))
+	if err != nil {
+		return 0
+	}
+	return strings.Count(output, "reached")
+}
+
+// shellQuote quotes the argument for the shell of the VM, so that it is passed verbatim to the command.
+func shellQuote(argument string) string {
+	return "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+}
+
 // GenerateProviderID generates the KubeVirt provider ID to be used for the NodeRef
 func (m *Machine) GenerateProviderID() (string, error) {
 	if m.vmiInstance == nil {
@@ -191,7 +389,7 @@ func (m *Machine) GenerateProviderID() (string, error) {
 
 // Delete deletes VM for this machine.
 func (m *Machine) Delete() error {
-	namespacedName := types.NamespacedName{Namespace: m.machineContext.KubevirtMachine.Namespace, Name: m.machineContext.KubevirtMachine.Name}
+	namespacedName := types.NamespacedName{Namespace: m.namespace, Name: m.machineContext.VMName()}
 	vm := &kubevirtv1.VirtualMachine{}
 	if err := m.client.Get(m.machineContext.Context, namespacedName, vm); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -200,6 +398,18 @@ func (m *Machine) Delete() error {
 		}
 	}
 
+	// Halt the VM first, so that KubeVirt does not start a new VMI while the VM is deleted, e.g. for a VM with the
+	// Always run strategy.
+	if vm.DeletionTimestamp.IsZero() && (vm.Spec.RunStrategy == nil || *vm.Spec.RunStrategy != kubevirtv1.RunStrategyHalted) {
+		mergePatch := client.MergeFrom(vm.DeepCopy())
+		halted := kubevirtv1.RunStrategyHalted
+		vm.Spec.RunStrategy = &halted
+		vm.Spec.Running = nil
+		if err := m.client.Patch(m.machineContext.Context, vm, mergePatch); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to halt VM")
+		}
+	}
+
 	if err := m.client.Delete(gocontext.Background(), vm); err != nil {
 		return errors.Wrapf(err, "failed to delete VM")
 	}
@@ -24,6 +24,8 @@ type MachineInterface interface {
 	Exists() bool
 	// IsReady checks if the VM is ready
 	IsReady() bool
+	// IsAgentConnected checks if the guest agent of the VM is connected.
+	IsAgentConnected() bool
 	// Address returns the IP address of the VM.
 	Address() string
 	// SupportsCheckingIsBootstrapped checks if we have a method of checking
@@ -31,6 +33,12 @@ type MachineInterface interface {
 	SupportsCheckingIsBootstrapped() bool
 	// IsBootstrapped checks if the VM is bootstrapped with Kubernetes.
 	IsBootstrapped() bool
+	// IsStarted checks if the startup probe of the machine succeeds against the VM.
+	IsStarted() bool
+	// BootstrapProgress returns the number of bootstrap markers of the cluster reached by the VM.
+	BootstrapProgress() int
+	// Reboot reboots the guest by restarting the VMI of the VM.
+	Reboot() error
 	// GenerateProviderID generates the KubeVirt provider ID to be used for the NodeRef
 	GenerateProviderID() (string, error)
 }
@@ -19,15 +19,27 @@ package kubevirt
 import (
 	gocontext "context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -234,6 +246,51 @@ var _ = Describe("With KubeVirt VM running", func() {
 		Expect(externalMachine.IsBootstrapped()).To(BeTrue())
 	})
 
+	It("BootstrapProgress should stop at the first marker not reached", func() {
+		machineContext.KubevirtCluster = kubevirtCluster.DeepCopy()
+		machineContext.KubevirtCluster.Spec.BootstrapMarkers = []infrav1.BootstrapMarker{
+			{Name: "bootstrap", Path: "/run/cluster-api/bootstrap-success.complete"},
+			{Name: "not reached", Path: "/run/not-reached"},
+			{Name: "bootstrap again", Path: "/run/cluster-api/bootstrap-success.complete"},
+		}
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte(sshKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.BootstrapProgress()).To(Equal(1))
+	})
+
+	It("BootstrapProgress should quote the marker paths", func() {
+		Expect(shellQuote("/run/done; rm -rf /")).To(Equal("'/run/done; rm -rf /'"))
+		Expect(shellQuote("/run/it's done")).To(Equal(`'/run/it'\''s done'`))
+	})
+
+	It("IsBootstrapped should check the bootstrap over the pod IP in the PodIP SSH address mode", func() {
+		machineContext.KubevirtCluster = kubevirtCluster.DeepCopy()
+		machineContext.KubevirtCluster.Spec.SSHAddressMode = infrav1.SSHAddressPodIP
+
+		vmi := virtualMachineInstance.DeepCopy()
+		vmi.UID = "vmi-uid"
+		launcherPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: vmi.Namespace,
+				Name:      "virt-launcher-" + vmi.Name,
+				Labels:    map[string]string{kubevirtv1.CreatedByLabel: "vmi-uid"},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.128.0.42"},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(vmi, virtualMachine, launcherPod).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte(sshKey))
+		Expect(err).NotTo(HaveOccurred())
+		sshAddresses := []string{}
+		externalMachine.getCommandExecutor = func(address string, _ *ssh.ClusterNodeSshKeys) ssh.VMCommandExecutor {
+			sshAddresses = append(sshAddresses, address)
+			return fakeVMCommandExecutor
+		}
+
+		Expect(externalMachine.IsBootstrapped()).To(BeTrue())
+		Expect(sshAddresses).To(Equal([]string{"10.128.0.42"}))
+	})
+
 	It("SupportsCheckingIsBootstrapped should return true", func() {
 		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte(sshKey))
 		Expect(err).NotTo(HaveOccurred())
@@ -298,6 +355,745 @@ var _ = Describe("util functions", func() {
 	})
 })
 
+var _ = Describe("NodeInstanceType", func() {
+	var machineContext *context.MachineContext
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:         gocontext.TODO(),
+			Cluster:         cluster,
+			KubevirtCluster: kubevirtCluster,
+			Machine:         machine,
+			KubevirtMachine: kubevirtMachine.DeepCopy(),
+			Logger:          logger,
+		}
+	})
+
+	It("should be empty when not opted in", func() {
+		Expect(NodeInstanceType(machineContext)).To(BeEmpty())
+	})
+
+	It("should use the configured name", func() {
+		machineContext.KubevirtMachine.Spec.NodeInstanceType = &infrav1.NodeInstanceType{Name: "small"}
+		Expect(NodeInstanceType(machineContext)).To(Equal("small"))
+	})
+
+	It("should synthesize the name from CPU and memory", func() {
+		machineContext.KubevirtMachine.Spec.NodeInstanceType = &infrav1.NodeInstanceType{}
+		guestMemory := resource.MustParse("4Gi")
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.CPU = &kubevirtv1.CPU{Cores: 2}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Memory = &kubevirtv1.Memory{Guest: &guestMemory}
+		Expect(NodeInstanceType(machineContext)).To(Equal("kubevirt-2c-4Gi"))
+	})
+
+	It("should prefer the flavor of the VM over its CPU and memory", func() {
+		machineContext.KubevirtMachine.Spec.NodeInstanceType = &infrav1.NodeInstanceType{}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor = &kubevirtv1.FlavorMatcher{Name: "medium"}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.CPU = &kubevirtv1.CPU{Cores: 2}
+		Expect(NodeInstanceType(machineContext)).To(Equal("medium"))
+	})
+})
+
+var _ = Describe("Storage profile defaults", func() {
+	var machineContext *context.MachineContext
+
+	storageClassName := "fast"
+	blockMode := corev1.PersistentVolumeBlock
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.DataVolumeTemplates = []kubevirtv1.DataVolumeTemplateSpec{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "dv1"},
+				Spec: cdiv1.DataVolumeSpec{
+					PVC: &corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &storageClassName,
+					},
+				},
+			},
+		}
+	})
+
+	getDataVolumePVC := func() *corev1.PersistentVolumeClaimSpec {
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+		Expect(vm.Spec.DataVolumeTemplates).To(HaveLen(1))
+		return vm.Spec.DataVolumeTemplates[0].Spec.PVC
+	}
+
+	It("should default access and volume mode from the storage profile", func() {
+		storageProfile := &cdiv1.StorageProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: storageClassName},
+			Status: cdiv1.StorageProfileStatus{
+				ClaimPropertySets: []cdiv1.ClaimPropertySet{
+					{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+						VolumeMode:  &blockMode,
+					},
+				},
+			},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(storageProfile).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		pvc := getDataVolumePVC()
+		Expect(pvc.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}))
+		Expect(pvc.VolumeMode).ToNot(BeNil())
+		Expect(*pvc.VolumeMode).To(Equal(corev1.PersistentVolumeBlock))
+	})
+
+	It("should leave the DataVolume untouched when no storage profile exists", func() {
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		pvc := getDataVolumePVC()
+		Expect(pvc.AccessModes).To(BeEmpty())
+		Expect(pvc.VolumeMode).To(BeNil())
+	})
+})
+
+var _ = Describe("Disk cache mode enforcement", func() {
+	It("should override the cache mode of DataVolume backed disks", func() {
+		cacheModeCluster := kubevirtCluster.DeepCopy()
+		cacheModeCluster.Spec.DiskCacheMode = kubevirtv1.CacheNone
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     cacheModeCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		vmSpec := &machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec
+		vmSpec.DataVolumeTemplates = []kubevirtv1.DataVolumeTemplateSpec{
+			{ObjectMeta: metav1.ObjectMeta{Name: "root"}},
+		}
+		vmSpec.Template.Spec.Volumes = []kubevirtv1.Volume{
+			{Name: "rootdisk", VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: "root"}}},
+			{Name: "scratch", VolumeSource: kubevirtv1.VolumeSource{ContainerDisk: &kubevirtv1.ContainerDiskSource{Image: "scratch"}}},
+		}
+		vmSpec.Template.Spec.Domain.Devices.Disks = []kubevirtv1.Disk{
+			{Name: "rootdisk", Cache: kubevirtv1.CacheWriteThrough},
+			{Name: "scratch", Cache: kubevirtv1.CacheWriteThrough},
+		}
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		disks := vm.Spec.Template.Spec.Domain.Devices.Disks
+		Expect(disks[0].Name).To(Equal("rootdisk"))
+		Expect(disks[0].Cache).To(Equal(kubevirtv1.CacheNone))
+		Expect(disks[1].Name).To(Equal("scratch"))
+		Expect(disks[1].Cache).To(Equal(kubevirtv1.CacheWriteThrough))
+
+		overrides := DiskCacheModeOverrides(machineContext)
+		Expect(overrides).To(HaveLen(1))
+		Expect(overrides[0].Name).To(Equal("rootdisk"))
+		Expect(overrides[0].Cache).To(Equal(kubevirtv1.CacheWriteThrough))
+	})
+})
+
+var _ = Describe("Firmware UUID", func() {
+	var machineContext *context.MachineContext
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.UID = "2bd3a9d4-6b7e-4c34-9a51-0f4c2b2d7e11"
+	})
+
+	It("should derive a stable firmware UUID from the machine", func() {
+		machineContext.KubevirtMachine.Spec.StableFirmwareUUID = true
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		recreatedVM := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Firmware.UUID).To(Equal(machineContext.KubevirtMachine.UID))
+		Expect(recreatedVM.Spec.Template.Spec.Domain.Firmware.UUID).To(Equal(vm.Spec.Template.Spec.Domain.Firmware.UUID))
+	})
+
+	It("should prefer the firmware UUID of the machine", func() {
+		machineContext.KubevirtMachine.Spec.StableFirmwareUUID = true
+		machineContext.KubevirtMachine.Spec.FirmwareUUID = "5d307ca9-b3ef-428c-8861-06e72d69f223"
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Firmware.UUID).To(Equal(types.UID("5d307ca9-b3ef-428c-8861-06e72d69f223")))
+	})
+
+	It("should leave the firmware UUID to the VirtualMachineTemplate by default", func() {
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Firmware).To(BeNil())
+	})
+})
+
+var _ = Describe("Disk block size", func() {
+	It("should set the block size on the disks of the VM", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Devices.Disks = []kubevirtv1.Disk{
+			{Name: "rootdisk"},
+			{Name: "datadisk"},
+			{Name: "scratch"},
+		}
+		machineContext.KubevirtMachine.Spec.DiskBlockSizes = []infrav1.DiskBlockSize{
+			{Disk: "rootdisk", Logical: 4096, Physical: 4096},
+			{Disk: "datadisk", MatchVolume: true},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+
+		disks := vm.Spec.Template.Spec.Domain.Devices.Disks
+		Expect(disks[0].BlockSize).To(Equal(&kubevirtv1.BlockSize{Custom: &kubevirtv1.CustomBlockSize{Logical: 4096, Physical: 4096}}))
+		Expect(disks[1].BlockSize.MatchVolume).NotTo(BeNil())
+		Expect(*disks[1].BlockSize.MatchVolume.Enabled).To(BeTrue())
+		Expect(disks[2].BlockSize).To(BeNil())
+	})
+})
+
+var _ = Describe("Disk IO mode", func() {
+	It("should set the IO mode on the disks of the VM", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Devices.Disks = []kubevirtv1.Disk{
+			{Name: "rootdisk"},
+			{Name: "scratch"},
+		}
+		machineContext.KubevirtMachine.Spec.DiskIOModes = []infrav1.DiskIOMode{
+			{Disk: "rootdisk", IO: kubevirtv1.IONative},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+
+		disks := vm.Spec.Template.Spec.Domain.Devices.Disks
+		Expect(disks[0].IO).To(Equal(kubevirtv1.IONative))
+		Expect(disks[1].IO).To(BeEmpty())
+	})
+})
+
+var _ = Describe("IO threads", func() {
+	It("should set the IO threads policy and the dedicated IO threads of the VM", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Devices.Disks = []kubevirtv1.Disk{
+			{Name: "rootdisk"},
+			{Name: "scratch"},
+		}
+		machineContext.KubevirtMachine.Spec.IOThreads = &infrav1.IOThreads{
+			Policy:         kubevirtv1.IOThreadsPolicyAuto,
+			DedicatedDisks: []string{"rootdisk"},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+
+		domain := vm.Spec.Template.Spec.Domain
+		Expect(domain.IOThreadsPolicy).NotTo(BeNil())
+		Expect(*domain.IOThreadsPolicy).To(Equal(kubevirtv1.IOThreadsPolicyAuto))
+		Expect(domain.Devices.Disks[0].DedicatedIOThread).NotTo(BeNil())
+		Expect(*domain.Devices.Disks[0].DedicatedIOThread).To(BeTrue())
+		Expect(domain.Devices.Disks[1].DedicatedIOThread).To(BeNil())
+	})
+})
+
+var _ = Describe("Autoattached devices", func() {
+	var machineContext *context.MachineContext
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+	})
+
+	It("should leave the KubeVirt defaults when unset", func() {
+		devices := newVirtualMachineFromKubevirtMachine(machineContext, "default").Spec.Template.Spec.Domain.Devices
+		Expect(devices.AutoattachSerialConsole).To(BeNil())
+		Expect(devices.AutoattachGraphicsDevice).To(BeNil())
+		Expect(devices.AutoattachMemBalloon).To(BeNil())
+	})
+
+	It("should keep the settings of the VirtualMachineTemplate", func() {
+		attach := true
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Devices.AutoattachGraphicsDevice = &attach
+
+		devices := newVirtualMachineFromKubevirtMachine(machineContext, "default").Spec.Template.Spec.Domain.Devices
+		Expect(*devices.AutoattachGraphicsDevice).To(BeTrue())
+	})
+
+	It("should set the devices of the machine on the VM", func() {
+		attach, detach := true, false
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.Devices.AutoattachMemBalloon = &attach
+		machineContext.KubevirtMachine.Spec.AutoattachSerialConsole = &detach
+		machineContext.KubevirtMachine.Spec.AutoattachGraphicsDevice = &attach
+		machineContext.KubevirtMachine.Spec.AutoattachMemBalloon = &detach
+
+		devices := newVirtualMachineFromKubevirtMachine(machineContext, "default").Spec.Template.Spec.Domain.Devices
+		Expect(*devices.AutoattachSerialConsole).To(BeFalse())
+		Expect(*devices.AutoattachGraphicsDevice).To(BeTrue())
+		Expect(*devices.AutoattachMemBalloon).To(BeFalse())
+	})
+})
+
+var _ = Describe("Startup probe", func() {
+	var machineContext *context.MachineContext
+
+	startupProbe := &kubevirtv1.Probe{
+		Handler:          kubevirtv1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(22)}},
+		PeriodSeconds:    10,
+		FailureThreshold: 30,
+	}
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.StartupProbe = startupProbe
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.LivenessProbe = &kubevirtv1.Probe{
+			Handler:             kubevirtv1.Handler{GuestAgentPing: &kubevirtv1.GuestAgentPing{}},
+			InitialDelaySeconds: 30,
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+	})
+
+	It("should delay the liveness probe of the VM without gating its readiness", func() {
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+		Expect(vm.Spec.Template.Spec.ReadinessProbe).To(BeNil())
+		Expect(vm.Spec.Template.Spec.LivenessProbe.InitialDelaySeconds).To(Equal(int32(330)))
+	})
+
+	Context("IsStarted", func() {
+		var vmi *kubevirtv1.VirtualMachineInstance
+
+		BeforeEach(func() {
+			vmi = virtualMachineInstance.DeepCopy()
+			vmi.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{
+				{
+					Type:   kubevirtv1.VirtualMachineInstanceReady,
+					Status: corev1.ConditionTrue,
+				},
+			}
+			vmi.Status.Interfaces = []kubevirtv1.VirtualMachineInstanceNetworkInterface{{IP: "127.0.0.1"}}
+		})
+
+		isStarted := func(probe *kubevirtv1.Probe) bool {
+			machineContext.KubevirtMachine.Spec.StartupProbe = probe
+			fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).WithObjects(vmi, virtualMachine).Build()
+			externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+			Expect(err).NotTo(HaveOccurred())
+			return externalMachine.IsStarted()
+		}
+
+		serverPort := func(server *httptest.Server) intstr.IntOrString {
+			serverURL, err := url.Parse(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			port, err := strconv.Atoi(serverURL.Port())
+			Expect(err).NotTo(HaveOccurred())
+			return intstr.FromInt(port)
+		}
+
+		It("should succeed without startup probe", func() {
+			Expect(isStarted(nil)).To(BeTrue())
+		})
+
+		It("should not succeed while the VM is not ready", func() {
+			vmi.Status.Conditions = nil
+			Expect(isStarted(&kubevirtv1.Probe{Handler: kubevirtv1.Handler{GuestAgentPing: &kubevirtv1.GuestAgentPing{}}})).To(BeFalse())
+		})
+
+		It("should wait for the guest agent to connect", func() {
+			probe := &kubevirtv1.Probe{Handler: kubevirtv1.Handler{GuestAgentPing: &kubevirtv1.GuestAgentPing{}}}
+			Expect(isStarted(probe)).To(BeFalse())
+
+			vmi.Status.Conditions = append(vmi.Status.Conditions, kubevirtv1.VirtualMachineInstanceCondition{
+				Type:   kubevirtv1.VirtualMachineInstanceAgentConnected,
+				Status: corev1.ConditionTrue,
+			})
+			Expect(isStarted(probe)).To(BeTrue())
+		})
+
+		It("should open a TCP connection to the VM", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			port := serverPort(server)
+			probe := &kubevirtv1.Probe{Handler: kubevirtv1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: port}}}
+			Expect(isStarted(probe)).To(BeTrue())
+
+			server.Close()
+			Expect(isStarted(probe)).To(BeFalse())
+		})
+
+		It("should send an HTTP GET request to the VM", func() {
+			healthy := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !healthy || r.URL.Path != "/healthz" || r.Header.Get("X-Probe") != "startup" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			probe := &kubevirtv1.Probe{Handler: kubevirtv1.Handler{HTTPGet: &corev1.HTTPGetAction{
+				Path:        "/healthz",
+				Port:        serverPort(server),
+				HTTPHeaders: []corev1.HTTPHeader{{Name: "X-Probe", Value: "startup"}},
+			}}}
+			Expect(isStarted(probe)).To(BeFalse())
+
+			healthy = true
+			Expect(isStarted(probe)).To(BeTrue())
+		})
+
+		It("should bound the time spent probing a VM which does not answer", func() {
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+			defer server.Close()
+			defer close(release)
+
+			probe := &kubevirtv1.Probe{
+				Handler:        kubevirtv1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: serverPort(server)}},
+				TimeoutSeconds: 60,
+			}
+			start := time.Now()
+			Expect(isStarted(probe)).To(BeFalse())
+			Expect(time.Since(start)).To(BeNumerically("<", 2*maxStartupProbeTimeout))
+		})
+	})
+})
+
+var _ = Describe("EffectiveResources", func() {
+	It("should default the guest memory to the memory request", func() {
+		vmi := &kubevirtv1.VirtualMachineInstance{}
+		vmi.Spec.Domain.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("3Gi"),
+		}
+
+		effectiveResources := EffectiveResources(vmi, nil)
+		Expect(effectiveResources.GuestMemory.String()).To(Equal("3Gi"))
+	})
+
+	It("should not report requests without virt-launcher pod", func() {
+		vmi := &kubevirtv1.VirtualMachineInstance{}
+		vmi.Spec.Domain.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("3Gi"),
+		}
+
+		effectiveResources := EffectiveResources(vmi, nil)
+		Expect(effectiveResources.CPURequest).To(BeNil())
+		Expect(effectiveResources.MemoryRequest).To(BeNil())
+	})
+})
+
+var _ = Describe("Input devices", func() {
+	It("should add the input devices to the VM", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		tablet := kubevirtv1.Input{Name: "tablet", Type: "tablet", Bus: "usb"}
+		machineContext.KubevirtMachine.Spec.Inputs = []kubevirtv1.Input{tablet}
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Devices.Inputs).To(Equal([]kubevirtv1.Input{tablet}))
+	})
+
+	It("should not add input devices by default", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Devices.Inputs).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Guest overhead overcommit", func() {
+	It("should overcommit the guest overhead when enabled", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.OvercommitGuestOverhead = true
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Resources.OvercommitGuestOverhead).To(BeTrue())
+	})
+
+	It("should not overcommit the guest overhead by default", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.Spec.Domain.Resources.OvercommitGuestOverhead).To(BeFalse())
+	})
+})
+
+var _ = Describe("Network interface multi-queue", func() {
+	var machineContext *context.MachineContext
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.NetworkInterfaceMultiqueue = infrav1.NetworkInterfaceMultiqueueAuto
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+	})
+
+	It("should enable multi-queue for a multi vCPU VM in auto mode", func() {
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.Domain.CPU = &kubevirtv1.CPU{Cores: 2, Sockets: 2}
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+		Expect(vm.Spec.Template.Spec.Domain.Devices.NetworkInterfaceMultiQueue).NotTo(BeNil())
+		Expect(*vm.Spec.Template.Spec.Domain.Devices.NetworkInterfaceMultiQueue).To(BeTrue())
+	})
+})
+
+var _ = Describe("Root boot source", func() {
+	It("should not create a VM with conflicting root boot sources", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		bootOrder := uint(1)
+		vmiSpec := &machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec
+		vmiSpec.Volumes = []kubevirtv1.Volume{
+			{Name: "rootdisk", VolumeSource: kubevirtv1.VolumeSource{ContainerDisk: &kubevirtv1.ContainerDiskSource{Image: "node-image"}}},
+		}
+		vmiSpec.Domain.Devices.Disks = []kubevirtv1.Disk{{Name: "rootdisk", BootOrder: &bootOrder}}
+		vmiSpec.Domain.Devices.Interfaces = []kubevirtv1.Interface{{Name: "default", BootOrder: &bootOrder}}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(MatchError(ContainSubstring("conflicting root boot sources")))
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(apierrors.IsNotFound(fakeClient.Get(machineContext.Context, key, vm))).To(BeTrue())
+	})
+})
+
+var _ = Describe("Descheduler eviction", func() {
+	var machineContext *context.MachineContext
+
+	BeforeEach(func() {
+		machineContext = &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine.DeepCopy(),
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+	})
+
+	It("should allow the eviction of worker machines by default", func() {
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.ObjectMeta.Annotations).To(HaveKeyWithValue(deschedulerEvictAnnotation, "true"))
+	})
+
+	It("should forbid the eviction of control plane machines by default", func() {
+		machineContext.Machine.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.ObjectMeta.Annotations).NotTo(HaveKey(deschedulerEvictAnnotation))
+	})
+
+	It("should reflect the configured setting", func() {
+		allow, forbid := true, false
+		machineContext.Machine.Labels[clusterv1.MachineControlPlaneLabelName] = ""
+		machineContext.KubevirtMachine.Spec.AllowDeschedulerEviction = &allow
+
+		vm := newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.ObjectMeta.Annotations).To(HaveKeyWithValue(deschedulerEvictAnnotation, "true"))
+
+		delete(machineContext.Machine.Labels, clusterv1.MachineControlPlaneLabelName)
+		machineContext.KubevirtMachine.Spec.AllowDeschedulerEviction = &forbid
+		machineContext.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.ObjectMeta.Annotations = map[string]string{
+			deschedulerEvictAnnotation: "true",
+		}
+
+		vm = newVirtualMachineFromKubevirtMachine(machineContext, "default")
+		Expect(vm.Spec.Template.ObjectMeta.Annotations).NotTo(HaveKey(deschedulerEvictAnnotation))
+	})
+})
+
+var _ = Describe("Virtiofs filesystems", func() {
+	It("should add the virtiofs devices and their volumes to the VM", func() {
+		machineContext := &context.MachineContext{
+			Context:             gocontext.TODO(),
+			Cluster:             cluster,
+			KubevirtCluster:     kubevirtCluster,
+			Machine:             machine,
+			KubevirtMachine:     kubevirtMachine.DeepCopy(),
+			BootstrapDataSecret: bootstrapDataSecret,
+			Logger:              logger,
+		}
+		machineContext.KubevirtMachine.Spec.Filesystems = []infrav1.VirtiofsFilesystem{
+			{
+				Name:                  "data",
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-data"},
+			},
+			{
+				Name:      "config",
+				ConfigMap: &corev1.LocalObjectReference{Name: "shared-config"},
+			},
+		}
+		fakeClient = fake.NewClientBuilder().WithScheme(setupScheme()).Build()
+
+		externalMachine, err := defaultTestMachine(machineContext, fakeClient, fakeVMCommandExecutor, []byte{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(externalMachine.Create(machineContext.Context)).To(Succeed())
+
+		vm := &kubevirtv1.VirtualMachine{}
+		key := client.ObjectKey{Name: machineContext.KubevirtMachine.Name, Namespace: machineContext.KubevirtMachine.Namespace}
+		Expect(fakeClient.Get(machineContext.Context, key, vm)).To(Succeed())
+
+		Expect(vm.Spec.Template.Spec.Domain.Devices.Filesystems).To(ConsistOf(
+			kubevirtv1.Filesystem{Name: "data", Virtiofs: &kubevirtv1.FilesystemVirtiofs{}},
+			kubevirtv1.Filesystem{Name: "config", Virtiofs: &kubevirtv1.FilesystemVirtiofs{}},
+		))
+		Expect(vm.Spec.Template.Spec.Volumes).To(ContainElements(
+			kubevirtv1.Volume{
+				Name: "data",
+				VolumeSource: kubevirtv1.VolumeSource{
+					PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+						PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared-data"},
+					},
+				},
+			},
+			kubevirtv1.Volume{
+				Name: "config",
+				VolumeSource: kubevirtv1.VolumeSource{
+					ConfigMap: &kubevirtv1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "shared-config"},
+					},
+				},
+			},
+		))
+	})
+})
+
 func validateVMNotExist(fakeClient client.Client, machineContext *context.MachineContext) {
 	vm := &kubevirtv1.VirtualMachine{}
 	key := client.ObjectKey{Name: virtualMachineInstance.Name, Namespace: virtualMachineInstance.Namespace}
@@ -331,6 +1127,15 @@ func setupScheme() *runtime.Scheme {
 	if err := corev1.AddToScheme(s); err != nil {
 		panic(err)
 	}
+	if err := storagev1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := schedulingv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
+	if err := cdiv1.AddToScheme(s); err != nil {
+		panic(err)
+	}
 	return s
 }
 
@@ -344,11 +1149,21 @@ func (e FakeVMCommandExecutor) ExecuteCommand(command string) (string, error) {
 		return "", errors.New("VM not found")
 	}
 
-	switch command {
-	case "hostname":
+	switch {
+	case command == "hostname":
 		return kubevirtMachineName, nil
-	case "cat /run/cluster-api/bootstrap-success.complete":
+	case command == "cat /run/cluster-api/bootstrap-success.complete":
 		return "success", nil
+	case strings.HasPrefix(command, "for marker in "):
+		// only the bootstrap success marker exists in the fake VM
+		reached := []string{}
+		for _, path := range strings.Fields(strings.TrimPrefix(strings.SplitN(command, ";", 2)[0], "for marker in ")) {
+			if path != "'/run/cluster-api/bootstrap-success.complete'" {
+				break
+			}
+			reached = append(reached, "reached")
+		}
+		return strings.Join(reached, "\n"), nil
 	default:
 		return "", errors.New("unexpected input argument")
 	}
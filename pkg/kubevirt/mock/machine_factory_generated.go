@@ -53,6 +53,20 @@ func (mr *MockMachineInterfaceMockRecorder) Address() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockMachineInterface)(nil).Address))
 }
 
+// BootstrapProgress mocks base method.
+func (m *MockMachineInterface) BootstrapProgress() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BootstrapProgress")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// BootstrapProgress indicates an expected call of BootstrapProgress.
+func (mr *MockMachineInterfaceMockRecorder) BootstrapProgress() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapProgress", reflect.TypeOf((*MockMachineInterface)(nil).BootstrapProgress))
+}
+
 // Create mocks base method.
 func (m *MockMachineInterface) Create(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -110,6 +124,20 @@ func (mr *MockMachineInterfaceMockRecorder) GenerateProviderID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateProviderID", reflect.TypeOf((*MockMachineInterface)(nil).GenerateProviderID))
 }
 
+// IsAgentConnected mocks base method.
+func (m *MockMachineInterface) IsAgentConnected() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAgentConnected")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsAgentConnected indicates an expected call of IsAgentConnected.
+func (mr *MockMachineInterfaceMockRecorder) IsAgentConnected() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAgentConnected", reflect.TypeOf((*MockMachineInterface)(nil).IsAgentConnected))
+}
+
 // IsBootstrapped mocks base method.
 func (m *MockMachineInterface) IsBootstrapped() bool {
 	m.ctrl.T.Helper()
@@ -138,6 +166,34 @@ func (mr *MockMachineInterfaceMockRecorder) IsReady() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsReady", reflect.TypeOf((*MockMachineInterface)(nil).IsReady))
 }
 
+// IsStarted mocks base method.
+func (m *MockMachineInterface) IsStarted() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsStarted")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsStarted indicates an expected call of IsStarted.
+func (mr *MockMachineInterfaceMockRecorder) IsStarted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsStarted", reflect.TypeOf((*MockMachineInterface)(nil).IsStarted))
+}
+
+// Reboot mocks base method.
+func (m *MockMachineInterface) Reboot() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reboot")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reboot indicates an expected call of Reboot.
+func (mr *MockMachineInterfaceMockRecorder) Reboot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reboot", reflect.TypeOf((*MockMachineInterface)(nil).Reboot))
+}
+
 // SupportsCheckingIsBootstrapped mocks base method.
 func (m *MockMachineInterface) SupportsCheckingIsBootstrapped() bool {
 	m.ctrl.T.Helper()
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	gocontext "context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// applyStorageProfileDefaults defaults the access modes and volume mode of the VM's DataVolumeTemplates
+// to the values recommended by the CDI StorageProfile of their storage class, when the user did not
+// specify them. DataVolumeTemplates are left untouched when no StorageProfile can be found.
+func applyStorageProfileDefaults(ctx gocontext.Context, c client.Client, vm *kubevirtv1.VirtualMachine) error {
+	for i := range vm.Spec.DataVolumeTemplates {
+		dvSpec := &vm.Spec.DataVolumeTemplates[i].Spec
+
+		var accessModes *[]corev1.PersistentVolumeAccessMode
+		var volumeMode **corev1.PersistentVolumeMode
+		var storageClassName *string
+		switch {
+		case dvSpec.PVC != nil:
+			accessModes, volumeMode, storageClassName = &dvSpec.PVC.AccessModes, &dvSpec.PVC.VolumeMode, dvSpec.PVC.StorageClassName
+		case dvSpec.Storage != nil:
+			accessModes, volumeMode, storageClassName = &dvSpec.Storage.AccessModes, &dvSpec.Storage.VolumeMode, dvSpec.Storage.StorageClassName
+		default:
+			continue
+		}
+
+		if len(*accessModes) > 0 && *volumeMode != nil {
+			continue
+		}
+
+		propertySets, err := storageProfileClaimPropertySets(ctx, c, storageClassName)
+		if err != nil {
+			return err
+		}
+
+		propertySet := matchClaimPropertySet(propertySets, *accessModes, *volumeMode)
+		if propertySet == nil {
+			continue
+		}
+
+		if len(*accessModes) == 0 {
+			*accessModes = append([]corev1.PersistentVolumeAccessMode{}, propertySet.AccessModes...)
+		}
+		if *volumeMode == nil && propertySet.VolumeMode != nil {
+			mode := *propertySet.VolumeMode
+			*volumeMode = &mode
+		}
+	}
+
+	return nil
+}
+
+// storageProfileClaimPropertySets returns the claim property sets recommended by the StorageProfile of
+// the given storage class, or of the default storage class when no storage class name is given.
+func storageProfileClaimPropertySets(ctx gocontext.Context, c client.Client, storageClassName *string) ([]cdiv1.ClaimPropertySet, error) {
+	name := ""
+	if storageClassName != nil {
+		name = *storageClassName
+	}
+
+	if name == "" {
+		storageClasses := &storagev1.StorageClassList{}
+		if err := c.List(ctx, storageClasses); err != nil {
+			if isStorageProfileLookupUnavailable(err) {
+				return nil, nil
+			}
+			return nil, errors.Wrap(err, "failed to list storage classes")
+		}
+		for _, storageClass := range storageClasses.Items {
+			if storageClass.Annotations[defaultStorageClassAnnotation] == "true" {
+				name = storageClass.Name
+				break
+			}
+		}
+		if name == "" {
+			return nil, nil
+		}
+	}
+
+	storageProfile := &cdiv1.StorageProfile{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, storageProfile); err != nil {
+		if isStorageProfileLookupUnavailable(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get storage profile %s", name)
+	}
+
+	return storageProfile.Status.ClaimPropertySets, nil
+}
+
+// matchClaimPropertySet returns the first claim property set that is compatible with the access modes
+// and volume mode already set by the user.
+func matchClaimPropertySet(propertySets []cdiv1.ClaimPropertySet, accessModes []corev1.PersistentVolumeAccessMode, volumeMode *corev1.PersistentVolumeMode) *cdiv1.ClaimPropertySet {
+	for i, propertySet := range propertySets {
+		if volumeMode != nil && (propertySet.VolumeMode == nil || *propertySet.VolumeMode != *volumeMode) {
+			continue
+		}
+		if len(accessModes) > 0 && !containsAccessModes(propertySet.AccessModes, accessModes) {
+			continue
+		}
+		return &propertySets[i]
+	}
+	return nil
+}
+
+func containsAccessModes(modes []corev1.PersistentVolumeAccessMode, required []corev1.PersistentVolumeAccessMode) bool {
+	for _, r := range required {
+		found := false
+		for _, m := range modes {
+			if m == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// isStorageProfileLookupUnavailable checks if the error means storage profiles can't be used on the
+// infra cluster, e.g. CDI is not installed or the infra credentials can't read cluster scoped objects.
+func isStorageProfileLookupUnavailable(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err)
+}
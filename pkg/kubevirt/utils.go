@@ -20,11 +20,14 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/kind/pkg/cluster/constants"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-kubevirt/api/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/context"
 )
 
@@ -81,7 +84,7 @@ func newVirtualMachineFromKubevirtMachine(ctx *context.MachineContext, namespace
 	virtualMachine.Kind = "VirtualMachine"
 
 	virtualMachine.ObjectMeta = metav1.ObjectMeta{
-		Name:      ctx.KubevirtMachine.Name,
+		Name:      ctx.VMName(),
 		Namespace: namespace,
 		Labels:    map[string]string{},
 	}
@@ -94,13 +97,13 @@ func newVirtualMachineFromKubevirtMachine(ctx *context.MachineContext, namespace
 		virtualMachine.ObjectMeta.Annotations = mapCopy(ctx.KubevirtMachine.Spec.VirtualMachineTemplate.ObjectMeta.Annotations)
 	}
 
-	virtualMachine.ObjectMeta.Labels["kubevirt.io/vm"] = ctx.KubevirtMachine.Name
-	virtualMachine.ObjectMeta.Labels["name"] = ctx.KubevirtMachine.Name
+	virtualMachine.ObjectMeta.Labels["kubevirt.io/vm"] = ctx.VMName()
+	virtualMachine.ObjectMeta.Labels["name"] = ctx.VMName()
 	virtualMachine.ObjectMeta.Labels["cluster.x-k8s.io/role"] = nodeRole(ctx)
 	virtualMachine.ObjectMeta.Labels["cluster.x-k8s.io/cluster-name"] = ctx.Cluster.Name
 
 	// make each datavolume unique by appending machine name as a prefix
-	virtualMachine = prefixDataVolumeTemplates(virtualMachine, ctx.KubevirtMachine.Name)
+	virtualMachine = prefixDataVolumeTemplates(virtualMachine, ctx.VMName())
 
 	return virtualMachine
 }
@@ -131,20 +134,96 @@ func buildVirtualMachineInstanceTemplate(ctx *context.MachineContext) *kubevirtv
 		template.ObjectMeta.Annotations = mapCopy(ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.ObjectMeta.Annotations)
 	}
 
-	template.ObjectMeta.Labels["kubevirt.io/vm"] = ctx.KubevirtMachine.Name
-	template.ObjectMeta.Labels["name"] = ctx.KubevirtMachine.Name
+	template.ObjectMeta.Labels["kubevirt.io/vm"] = ctx.VMName()
+	template.ObjectMeta.Labels["name"] = ctx.VMName()
 	template.ObjectMeta.Labels["cluster.x-k8s.io/role"] = nodeRole(ctx)
 	template.ObjectMeta.Labels["cluster.x-k8s.io/cluster-name"] = ctx.Cluster.Name
 
 	template.Spec = *ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template.Spec.DeepCopy()
 
+	// The guest hostname defaults to the VMI name, while the node is expected to be named after the machine.
+	if template.Spec.Hostname == "" && ctx.VMName() != ctx.KubevirtMachine.Name {
+		template.Spec.Hostname = ctx.KubevirtMachine.Name
+	}
+
+	// KubeVirt propagates the VMI annotations to the virt-launcher pod, which is the pod the descheduler evicts.
+	if allowEviction, ok := deschedulerEvictionAllowed(ctx); ok {
+		if allowEviction {
+			template.ObjectMeta.Annotations[deschedulerEvictAnnotation] = "true"
+		} else {
+			delete(template.ObjectMeta.Annotations, deschedulerEvictAnnotation)
+		}
+	}
+
+	// KubeVirt propagates the VMI priority class to the virt-launcher pod.
+	if ctx.KubevirtMachine.Spec.PriorityClassName != "" {
+		template.Spec.PriorityClassName = ctx.KubevirtMachine.Spec.PriorityClassName
+	}
+
+	if ctx.KubevirtMachine.Spec.OvercommitGuestOverhead {
+		template.Spec.Domain.Resources.OvercommitGuestOverhead = true
+	}
+
+	// KubeVirt sizes the queues of each network interface to the vCPU count of the VM.
+	if ctx.KubevirtMachine.Spec.NetworkInterfaceMultiqueue == infrav1.NetworkInterfaceMultiqueueAuto {
+		multiqueue := true
+		template.Spec.Domain.Devices.NetworkInterfaceMultiQueue = &multiqueue
+	}
+
+	for _, blockSize := range ctx.KubevirtMachine.Spec.DiskBlockSizes {
+		for i := range template.Spec.Domain.Devices.Disks {
+			if disk := &template.Spec.Domain.Devices.Disks[i]; disk.Name == blockSize.Disk {
+				disk.BlockSize = diskBlockSize(blockSize)
+			}
+		}
+	}
+
+	for _, ioMode := range ctx.KubevirtMachine.Spec.DiskIOModes {
+		for i := range template.Spec.Domain.Devices.Disks {
+			if disk := &template.Spec.Domain.Devices.Disks[i]; disk.Name == ioMode.Disk {
+				disk.IO = ioMode.IO
+			}
+		}
+	}
+
+	if uuid := firmwareUUID(ctx); uuid != "" {
+		if template.Spec.Domain.Firmware == nil {
+			template.Spec.Domain.Firmware = &kubevirtv1.Firmware{}
+		}
+		template.Spec.Domain.Firmware.UUID = uuid
+	}
+
+	if startupProbe := ctx.KubevirtMachine.Spec.StartupProbe; startupProbe != nil {
+		applyStartupProbe(template, startupProbe)
+	}
+
+	if ioThreads := ctx.KubevirtMachine.Spec.IOThreads; ioThreads != nil {
+		policy := ioThreads.Policy
+		template.Spec.Domain.IOThreadsPolicy = &policy
+		for _, dedicatedDisk := range ioThreads.DedicatedDisks {
+			for i := range template.Spec.Domain.Devices.Disks {
+				if disk := &template.Spec.Domain.Devices.Disks[i]; disk.Name == dedicatedDisk {
+					dedicated := true
+					disk.DedicatedIOThread = &dedicated
+				}
+			}
+		}
+	}
+
+	devices := &template.Spec.Domain.Devices
+	devices.AutoattachSerialConsole = autoattach(ctx.KubevirtMachine.Spec.AutoattachSerialConsole, devices.AutoattachSerialConsole)
+	devices.AutoattachGraphicsDevice = autoattach(ctx.KubevirtMachine.Spec.AutoattachGraphicsDevice, devices.AutoattachGraphicsDevice)
+	devices.AutoattachMemBalloon = autoattach(ctx.KubevirtMachine.Spec.AutoattachMemBalloon, devices.AutoattachMemBalloon)
+
+	enforceDiskCacheMode(ctx, template, ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.DataVolumeTemplates)
+
 	cloudInitVolumeName := "cloudinitvolume"
 	cloudInitVolume := kubevirtv1.Volume{
 		Name: cloudInitVolumeName,
 		VolumeSource: kubevirtv1.VolumeSource{
 			CloudInitConfigDrive: &kubevirtv1.CloudInitConfigDriveSource{
 				UserDataSecretRef: &corev1.LocalObjectReference{
-					Name: *ctx.Machine.Spec.Bootstrap.DataSecretName + "-userdata",
+					Name: ctx.UserDataSecretName(),
 				},
 			},
 		},
@@ -161,9 +240,141 @@ func buildVirtualMachineInstanceTemplate(ctx *context.MachineContext) *kubevirtv
 	}
 	template.Spec.Domain.Devices.Disks = append(template.Spec.Domain.Devices.Disks, cloudInitDisk)
 
+	template.Spec.Domain.Devices.Inputs = append(template.Spec.Domain.Devices.Inputs, ctx.KubevirtMachine.Spec.Inputs...)
+
+	for _, filesystem := range ctx.KubevirtMachine.Spec.Filesystems {
+		template.Spec.Volumes = append(template.Spec.Volumes, filesystemVolume(filesystem))
+		template.Spec.Domain.Devices.Filesystems = append(template.Spec.Domain.Devices.Filesystems, kubevirtv1.Filesystem{
+			Name:     filesystem.Name,
+			Virtiofs: &kubevirtv1.FilesystemVirtiofs{},
+		})
+	}
+
 	return template
 }
 
+// autoattach returns whether a device is attached to the VM: the setting of the machine when set, else the setting of
+// the VirtualMachineTemplate. When both are unset, nil is returned so that the KubeVirt default applies.
+func autoattach(machineSetting, templateSetting *bool) *bool {
+	if machineSetting != nil {
+		return machineSetting
+	}
+	return templateSetting
+}
+
+// firmwareUUID returns the SMBIOS UUID of the VM set by the machine, or an empty UID when the UUID is left to the
+// VirtualMachineTemplate.
+func firmwareUUID(ctx *context.MachineContext) types.UID {
+	if ctx.KubevirtMachine.Spec.FirmwareUUID != "" {
+		return ctx.KubevirtMachine.Spec.FirmwareUUID
+	}
+	if ctx.KubevirtMachine.Spec.StableFirmwareUUID {
+		// the UID of the KubevirtMachine is a random UUID, which does not change while the machine exists
+		return ctx.KubevirtMachine.UID
+	}
+	return ""
+}
+
+// applyStartupProbe delays the liveness probe of the VM until the startup probe, which KubeVirt does not support and
+// the controller runs instead, ran out of attempts.
+func applyStartupProbe(template *kubevirtv1.VirtualMachineInstanceTemplateSpec, startupProbe *kubevirtv1.Probe) {
+	if template.Spec.LivenessProbe != nil {
+		template.Spec.LivenessProbe.InitialDelaySeconds += startupProbeDuration(startupProbe)
+	}
+}
+
+// startupProbeDuration returns the seconds a startup probe may take to succeed before it gives up, using the
+// Kubernetes probe defaults.
+func startupProbeDuration(probe *kubevirtv1.Probe) int32 {
+	periodSeconds := probe.PeriodSeconds
+	if periodSeconds == 0 {
+		periodSeconds = 10
+	}
+	failureThreshold := probe.FailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = 3
+	}
+	return probe.InitialDelaySeconds + periodSeconds*failureThreshold
+}
+
+// diskBlockSize returns the KubeVirt block size of a disk.
+func diskBlockSize(blockSize infrav1.DiskBlockSize) *kubevirtv1.BlockSize {
+	if blockSize.MatchVolume {
+		enabled := true
+		return &kubevirtv1.BlockSize{MatchVolume: &kubevirtv1.FeatureState{Enabled: &enabled}}
+	}
+	return &kubevirtv1.BlockSize{
+		Custom: &kubevirtv1.CustomBlockSize{Logical: blockSize.Logical, Physical: blockSize.Physical},
+	}
+}
+
+// enforceDiskCacheMode sets the cache mode enforced by the cluster on the DataVolume backed disks of the VM.
+func enforceDiskCacheMode(ctx *context.MachineContext, template *kubevirtv1.VirtualMachineInstanceTemplateSpec, dataVolumeTemplates []kubevirtv1.DataVolumeTemplateSpec) {
+	if ctx.KubevirtCluster == nil || ctx.KubevirtCluster.Spec.DiskCacheMode == "" {
+		return
+	}
+
+	dataVolumeDisks := dataVolumeDiskNames(template, dataVolumeTemplates)
+	for i, disk := range template.Spec.Domain.Devices.Disks {
+		if dataVolumeDisks[disk.Name] {
+			template.Spec.Domain.Devices.Disks[i].Cache = ctx.KubevirtCluster.Spec.DiskCacheMode
+		}
+	}
+}
+
+// DiskCacheModeOverrides returns the DataVolume backed disks of the VirtualMachineTemplate of the machine whose cache
+// mode is overridden by the cache mode enforced by the cluster.
+func DiskCacheModeOverrides(ctx *context.MachineContext) []kubevirtv1.Disk {
+	vmTemplate := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec
+	if ctx.KubevirtCluster == nil || ctx.KubevirtCluster.Spec.DiskCacheMode == "" || vmTemplate.Template == nil {
+		return nil
+	}
+
+	dataVolumeDisks := dataVolumeDiskNames(vmTemplate.Template, vmTemplate.DataVolumeTemplates)
+	overrides := []kubevirtv1.Disk{}
+	for _, disk := range vmTemplate.Template.Spec.Domain.Devices.Disks {
+		if dataVolumeDisks[disk.Name] && disk.Cache != "" && disk.Cache != ctx.KubevirtCluster.Spec.DiskCacheMode {
+			overrides = append(overrides, disk)
+		}
+	}
+	return overrides
+}
+
+// dataVolumeDiskNames returns the names of the disks of the VM backed by a DataVolume.
+func dataVolumeDiskNames(template *kubevirtv1.VirtualMachineInstanceTemplateSpec, dataVolumeTemplates []kubevirtv1.DataVolumeTemplateSpec) map[string]bool {
+	dataVolumes := map[string]bool{}
+	for _, dataVolumeTemplate := range dataVolumeTemplates {
+		dataVolumes[dataVolumeTemplate.Name] = true
+	}
+
+	dataVolumeDisks := map[string]bool{}
+	for _, volume := range template.Spec.Volumes {
+		switch {
+		case volume.DataVolume != nil:
+			dataVolumeDisks[volume.Name] = true
+		case volume.PersistentVolumeClaim != nil && dataVolumes[volume.PersistentVolumeClaim.ClaimName]:
+			dataVolumeDisks[volume.Name] = true
+		}
+	}
+	return dataVolumeDisks
+}
+
+// filesystemVolume returns the VM volume backing the given virtiofs filesystem.
+func filesystemVolume(filesystem infrav1.VirtiofsFilesystem) kubevirtv1.Volume {
+	volume := kubevirtv1.Volume{Name: filesystem.Name}
+	switch {
+	case filesystem.PersistentVolumeClaim != nil:
+		volume.PersistentVolumeClaim = &kubevirtv1.PersistentVolumeClaimVolumeSource{
+			PersistentVolumeClaimVolumeSource: *filesystem.PersistentVolumeClaim,
+		}
+	case filesystem.ConfigMap != nil:
+		volume.ConfigMap = &kubevirtv1.ConfigMapVolumeSource{
+			LocalObjectReference: *filesystem.ConfigMap,
+		}
+	}
+	return volume
+}
+
 // nodeRole returns the role of this node ("control-plane" or "worker").
 func nodeRole(ctx *context.MachineContext) string {
 	if util.IsControlPlaneMachine(ctx.Machine) {
@@ -171,3 +382,115 @@ func nodeRole(ctx *context.MachineContext) string {
 	}
 	return constants.WorkerNodeRoleValue
 }
+
+// deschedulerEvictAnnotation allows the descheduler to evict a pod it would otherwise not evict, e.g. a pod with
+// local storage like the virt-launcher pod of a VM.
+const deschedulerEvictAnnotation = "descheduler.alpha.kubernetes.io/evict"
+
+// deschedulerEvictionAllowed returns whether the descheduler may evict the VM of the machine. The second value is
+// false when the eviction is left to the annotations of the VirtualMachineTemplate.
+func deschedulerEvictionAllowed(ctx *context.MachineContext) (bool, bool) {
+	if allow := ctx.KubevirtMachine.Spec.AllowDeschedulerEviction; allow != nil {
+		return *allow, true
+	}
+	if vmiTemplate := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template; vmiTemplate != nil {
+		if _, ok := vmiTemplate.ObjectMeta.Annotations[deschedulerEvictAnnotation]; ok {
+			return false, false
+		}
+	}
+	return !util.IsControlPlaneMachine(ctx.Machine), true
+}
+
+// GuestHostname returns the hostname and the subdomain of the guest of the VM. The hostname of the guest is the name
+// of the machine, unless the VirtualMachineTemplate sets one.
+func GuestHostname(ctx *context.MachineContext) (string, string) {
+	hostname, subdomain := ctx.KubevirtMachine.Name, ""
+	if vmiTemplate := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template; vmiTemplate != nil {
+		if vmiTemplate.Spec.Hostname != "" {
+			hostname = vmiTemplate.Spec.Hostname
+		}
+		subdomain = vmiTemplate.Spec.Subdomain
+	}
+	return hostname, subdomain
+}
+
+// NodeInstanceType returns the instance type to be reported on the workload cluster node of this machine.
+// An empty string is returned when the machine does not opt into instance type reporting.
+func NodeInstanceType(ctx *context.MachineContext) string {
+	instanceType := ctx.KubevirtMachine.Spec.NodeInstanceType
+	if instanceType == nil {
+		return ""
+	}
+	if instanceType.Name != "" {
+		return instanceType.Name
+	}
+	// the flavor names the shape of the VM, the CPU and memory of the template may only be partially set
+	if flavor := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Flavor; flavor != nil && flavor.Name != "" {
+		return flavor.Name
+	}
+
+	vmiTemplate := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template
+	if vmiTemplate == nil {
+		return "kubevirt"
+	}
+	domain := vmiTemplate.Spec.Domain
+	cpus := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.VCPUs()
+
+	var memory *resource.Quantity
+	if domain.Memory != nil && domain.Memory.Guest != nil {
+		memory = domain.Memory.Guest
+	} else if requested, ok := domain.Resources.Requests[corev1.ResourceMemory]; ok {
+		memory = &requested
+	}
+
+	if memory == nil {
+		return fmt.Sprintf("kubevirt-%dc", cpus)
+	}
+	return fmt.Sprintf("kubevirt-%dc-%s", cpus, memory.String())
+}
+
+// EffectiveResources returns the resources of the running VMI: the requests of its virt-launcher pod, as computed by
+// KubeVirt from the resources of the VM, the memory and CPU overcommit of the infra cluster and the overhead of the
+// VM, and the guest memory of the VMI. The requests are not reported without a virt-launcher pod.
+func EffectiveResources(vmi *kubevirtv1.VirtualMachineInstance, launcherPod *corev1.Pod) *infrav1.EffectiveResources {
+	effectiveResources := &infrav1.EffectiveResources{}
+
+	if launcherPod != nil {
+		requests := corev1.ResourceList{}
+		for _, container := range launcherPod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				total := requests[name]
+				total.Add(quantity)
+				requests[name] = total
+			}
+		}
+		if cpu, ok := requests[corev1.ResourceCPU]; ok {
+			effectiveResources.CPURequest = &cpu
+		}
+		if memory, ok := requests[corev1.ResourceMemory]; ok {
+			effectiveResources.MemoryRequest = &memory
+		}
+	}
+
+	// KubeVirt defaults the guest memory to the memory request of the VMI
+	domain := vmi.Spec.Domain
+	if domain.Memory != nil && domain.Memory.Guest != nil {
+		guestMemory := domain.Memory.Guest.DeepCopy()
+		effectiveResources.GuestMemory = &guestMemory
+	} else if memory, ok := domain.Resources.Requests[corev1.ResourceMemory]; ok {
+		effectiveResources.GuestMemory = &memory
+	}
+
+	return effectiveResources
+}
+
+// PriorityClassName returns the name of the PriorityClass to be used by the VM of this machine.
+func PriorityClassName(ctx *context.MachineContext) string {
+	if ctx.KubevirtMachine.Spec.PriorityClassName != "" {
+		return ctx.KubevirtMachine.Spec.PriorityClassName
+	}
+	if vmiTemplate := ctx.KubevirtMachine.Spec.VirtualMachineTemplate.Spec.Template; vmiTemplate != nil {
+		return vmiTemplate.Spec.PriorityClassName
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MachineReadyEvent is sent when a machine becomes ready.
+	MachineReadyEvent = "Ready"
+
+	// MachineFailedEvent is sent when a machine fails to be provisioned.
+	MachineFailedEvent = "Failed"
+)
+
+// MachineEvent is the payload posted to the machine notification webhook.
+type MachineEvent struct {
+	Event      string                     `json:"event"`
+	Namespace  string                     `json:"namespace"`
+	Name       string                     `json:"name"`
+	ProviderID string                     `json:"providerID,omitempty"`
+	Addresses  []clusterv1.MachineAddress `json:"addresses,omitempty"`
+	Message    string                     `json:"message,omitempty"`
+}
+
+//go:generate mockgen -source=./notification.go -destination=./mock/notification_generated.go -package=mock
+type Notifier interface {
+	Notify(url string, event MachineEvent)
+}
+
+// New creates a notifier posting the events to the webhook in the background.
+func New(log logr.Logger) Notifier {
+	return &webhookNotifier{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		log:      log,
+		attempts: 3,
+		backoff:  2 * time.Second,
+	}
+}
+
+// webhookNotifier posts machine events to a webhook, retrying failed deliveries with an exponential backoff.
+type webhookNotifier struct {
+	client   *http.Client
+	log      logr.Logger
+	attempts int
+	backoff  time.Duration
+}
+
+// Notify posts the event to the webhook without waiting for the delivery.
+func (n *webhookNotifier) Notify(url string, event MachineEvent) {
+	go func() {
+		log := n.log.WithValues("url", url, "event", event.Event, "machine", event.Name)
+		backoff := n.backoff
+		for attempt := 1; ; attempt++ {
+			err := n.post(url, event)
+			if err == nil {
+				return
+			}
+			if attempt == n.attempts {
+				log.Error(err, "failed to notify the machine notification webhook, giving up")
+				return
+			}
+			log.Info("failed to notify the machine notification webhook, retrying", "error", err.Error())
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}()
+}
+
+func (n *webhookNotifier) post(url string, event MachineEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal machine event")
+	}
+
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to post machine event")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
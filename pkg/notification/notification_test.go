@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("webhook notifier", func() {
+	var (
+		lock     sync.Mutex
+		received []MachineEvent
+		failures int
+		server   *httptest.Server
+		notifier *webhookNotifier
+	)
+
+	event := MachineEvent{
+		Event:      MachineReadyEvent,
+		Namespace:  "default",
+		Name:       "test-machine",
+		ProviderID: "kubevirt://test-machine",
+		Addresses:  []clusterv1.MachineAddress{{Type: clusterv1.MachineInternalIP, Address: "1.1.1.1"}},
+	}
+
+	receivedEvents := func() []MachineEvent {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]MachineEvent{}, received...)
+	}
+
+	BeforeEach(func() {
+		received = nil
+		failures = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			machineEvent := MachineEvent{}
+			Expect(json.NewDecoder(r.Body).Decode(&machineEvent)).To(Succeed())
+			received = append(received, machineEvent)
+		}))
+		notifier = &webhookNotifier{
+			client:   server.Client(),
+			log:      ctrl.Log.WithName("test"),
+			attempts: 3,
+			backoff:  time.Millisecond,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should post the machine event", func() {
+		notifier.Notify(server.URL, event)
+		Eventually(receivedEvents).Should(Equal([]MachineEvent{event}))
+	})
+
+	It("should retry a failed delivery", func() {
+		failures = 2
+		notifier.Notify(server.URL, event)
+		Eventually(receivedEvents).Should(Equal([]MachineEvent{event}))
+	})
+
+	It("should give up after the last attempt", func() {
+		failures = 3
+		notifier.Notify(server.URL, event)
+		Consistently(receivedEvents, 100*time.Millisecond).Should(BeEmpty())
+	})
+})
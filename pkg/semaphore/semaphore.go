@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package semaphore
+
+import (
+	"sync"
+	"time"
+)
+
+// Semaphore limits the number of keys holding a permit at once. A key holds at most one permit, so acquiring a
+// permit again for the same key always succeeds. A nil Semaphore grants permits without limit.
+type Semaphore struct {
+	lock     sync.Mutex
+	capacity int
+	holders  map[string]time.Time
+}
+
+// New creates a semaphore granting at most capacity permits. A capacity of zero or less means no limit.
+func New(capacity int) *Semaphore {
+	return &Semaphore{
+		capacity: capacity,
+		holders:  map[string]time.Time{},
+	}
+}
+
+// TryAcquire acquires a permit for the key, without waiting. It returns false when all permits are held.
+func (s *Semaphore) TryAcquire(key string) bool {
+	if s == nil || s.capacity <= 0 {
+		return true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.holders[key]; ok {
+		return true
+	}
+	if len(s.holders) >= s.capacity {
+		return false
+	}
+	s.holders[key] = time.Now()
+	return true
+}
+
+// Acquire acquires a permit for the key, even when all permits are held, e.g. for a key which held a permit before
+// the process restarted. The other keys can't acquire a permit until enough permits are released.
+func (s *Semaphore) Acquire(key string) {
+	if s == nil || s.capacity <= 0 {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.holders[key]; !ok {
+		s.holders[key] = time.Now()
+	}
+}
+
+// HeldSince returns the time the key acquired its permit, and false when the key holds no permit.
+func (s *Semaphore) HeldSince(key string) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	since, ok := s.holders[key]
+	return since, ok
+}
+
+// Release releases the permit held by the key, if any.
+func (s *Semaphore) Release(key string) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.holders, key)
+}
+
+// Capacity returns the number of permits of the semaphore, zero meaning no limit.
+func (s *Semaphore) Capacity() int {
+	if s == nil || s.capacity <= 0 {
+		return 0
+	}
+	return s.capacity
+}
@@ -0,0 +1,13 @@
+package semaphore_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSemaphore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Semaphore Suite")
+}
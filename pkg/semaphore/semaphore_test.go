@@ -0,0 +1,72 @@
+package semaphore_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/semaphore"
+)
+
+var _ = Describe("Semaphore", func() {
+	It("should grant at most capacity permits", func() {
+		s := semaphore.New(2)
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		Expect(s.TryAcquire("b")).To(BeTrue())
+		Expect(s.TryAcquire("c")).To(BeFalse())
+		Expect(s.Capacity()).To(Equal(2))
+	})
+
+	It("should grant the permit again to the key holding it", func() {
+		s := semaphore.New(1)
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		since, held := s.HeldSince("a")
+		Expect(held).To(BeTrue())
+
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		heldSince, held := s.HeldSince("a")
+		Expect(held).To(BeTrue())
+		Expect(heldSince).To(Equal(since))
+		Expect(s.TryAcquire("b")).To(BeFalse())
+	})
+
+	It("should grant the released permit to another key", func() {
+		s := semaphore.New(1)
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		s.Release("a")
+		_, held := s.HeldSince("a")
+		Expect(held).To(BeFalse())
+
+		Expect(s.TryAcquire("b")).To(BeTrue())
+		Expect(s.TryAcquire("a")).To(BeFalse())
+	})
+
+	It("should ignore the release of a key without permit", func() {
+		s := semaphore.New(1)
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		s.Release("b")
+		Expect(s.TryAcquire("b")).To(BeFalse())
+	})
+
+	It("should acquire permits beyond capacity when forced", func() {
+		s := semaphore.New(1)
+		s.Acquire("a")
+		s.Acquire("b")
+		Expect(s.TryAcquire("c")).To(BeFalse())
+
+		s.Release("a")
+		Expect(s.TryAcquire("c")).To(BeFalse())
+		s.Release("b")
+		Expect(s.TryAcquire("c")).To(BeTrue())
+	})
+
+	It("should not limit a semaphore without capacity", func() {
+		s := semaphore.New(0)
+		Expect(s.TryAcquire("a")).To(BeTrue())
+		Expect(s.TryAcquire("b")).To(BeTrue())
+		Expect(s.Capacity()).To(Equal(0))
+
+		var nilSemaphore *semaphore.Semaphore
+		Expect(nilSemaphore.TryAcquire("a")).To(BeTrue())
+		nilSemaphore.Release("a")
+	})
+})
@@ -0,0 +1,582 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userdata
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	cloudConfigHeader = "#cloud-config\n"
+
+	kubeletExtraArgsVariable = "KUBELET_EXTRA_ARGS"
+)
+
+// kubeletListFlags are the kubelet flags taking a comma-separated list of entries, e.g. node-labels, which are
+// merged entry by entry rather than replaced.
+var kubeletListFlags = map[string]bool{
+	"node-labels":                true,
+	"register-with-taints":       true,
+	"feature-gates":              true,
+	"kube-reserved":              true,
+	"system-reserved":            true,
+	"eviction-hard":              true,
+	"eviction-soft":              true,
+	"eviction-soft-grace-period": true,
+	"eviction-minimum-reclaim":   true,
+}
+
+// environmentValueEscaper escapes the characters which are special in a double-quoted value of an environment file,
+// as read by systemd and shells.
+var environmentValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// trustBundlePath is the CA anchors directory of Fedora CoreOS and RHCOS, where update-ca-trust picks up
+// additional CAs while the node boots.
+const trustBundlePath = "/etc/pki/ca-trust/source/anchors/capk-trust-bundle.pem"
+
+// File describes a file to be written to the node before the bootstrap commands run.
+type File struct {
+	Path        string
+	Permissions int
+	Content     string
+}
+
+// IsCloudConfig checks if the user data is a cloud-init cloud-config document.
+func IsCloudConfig(userData []byte) bool {
+	return regexp.MustCompile(`(?m)^#cloud-config`).MatchString(string(userData))
+}
+
+// IsIgnition checks if the user data is an Ignition config.
+func IsIgnition(userData []byte) bool {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(userData, &config); err != nil {
+		return false
+	}
+	_, ok := config["ignition"]
+	return ok
+}
+
+// KubeletExtraArgsFiles returns the files setting KUBELET_EXTRA_ARGS for the kubelet systemd unit
+// installed by the kubeadm packages. The kubelet flags written by the bootstrap provider live in
+// a separate environment file, so both sets of flags are passed to the kubelet. When the user data
+// already writes the files, e.g. from the files of a KubeadmConfig, the flags are merged into them:
+// the values of the list flags like node-labels are merged, and the given flags take precedence
+// otherwise.
+func KubeletExtraArgsFiles(userData []byte, extraArgs map[string]string) ([]File, error) {
+	if len(extraArgs) == 0 {
+		return nil, nil
+	}
+	for key, value := range extraArgs {
+		// a new line would end the KUBELET_EXTRA_ARGS variable of the environment file
+		if strings.ContainsAny(key+value, "\r\n") {
+			return nil, errors.Errorf("kubelet flag %s must not contain a new line", key)
+		}
+	}
+
+	existingFiles, err := fileContents(userData)
+	if err != nil {
+		return nil, err
+	}
+
+	// debian based distributions read /etc/default/kubelet, rpm based ones /etc/sysconfig/kubelet.
+	files := []File{}
+	for _, path := range []string{"/etc/default/kubelet", "/etc/sysconfig/kubelet"} {
+		files = append(files, File{Path: path, Permissions: 0644, Content: mergeKubeletEnvironmentFile(existingFiles[path], extraArgs)})
+	}
+	return files, nil
+}
+
+// mergeKubeletEnvironmentFile merges the kubelet flags into the KUBELET_EXTRA_ARGS variable of the environment file,
+// keeping the other lines of the file. The variable is written as a double-quoted value, escaping its special
+// characters.
+func mergeKubeletEnvironmentFile(content string, extraArgs map[string]string) string {
+	lines := []string{}
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	argsLine := -1
+	flags := []string{}
+	for i, line := range lines {
+		if strings.HasPrefix(line, kubeletExtraArgsVariable+"=") {
+			argsLine = i
+			flags = strings.Fields(unquoteEnvironmentValue(strings.TrimPrefix(line, kubeletExtraArgsVariable+"=")))
+			break
+		}
+	}
+	if argsLine < 0 {
+		lines = append(lines, "")
+		argsLine = len(lines) - 1
+	}
+
+	keys := make([]string, 0, len(extraArgs))
+	for key := range extraArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, "--")
+		value := extraArgs[key]
+		merged := false
+		for i, flag := range flags {
+			nameValue := strings.SplitN(strings.TrimPrefix(flag, "--"), "=", 2)
+			if len(nameValue) != 2 || nameValue[0] != name {
+				continue
+			}
+			if kubeletListFlags[name] {
+				value = mergeListFlagValues(nameValue[1], value)
+			}
+			flags[i] = fmt.Sprintf("--%s=%s", name, value)
+			merged = true
+		}
+		if !merged {
+			flags = append(flags, fmt.Sprintf("--%s=%s", name, value))
+		}
+	}
+
+	lines[argsLine] = fmt.Sprintf("%s=\"%s\"", kubeletExtraArgsVariable, environmentValueEscaper.Replace(strings.Join(flags, " ")))
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// unquoteEnvironmentValue returns the value of a variable of an environment file without its quotes, and without the
+// escapes of a double-quoted value.
+func unquoteEnvironmentValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != value[len(value)-1] {
+		return value
+	}
+	switch value[0] {
+	case '\'':
+		return value[1 : len(value)-1]
+	case '"':
+		value = value[1 : len(value)-1]
+		unquoted := strings.Builder{}
+		for i := 0; i < len(value); i++ {
+			if value[i] == '\\' && i+1 < len(value) && strings.ContainsRune("\\\"$`", rune(value[i+1])) {
+				i++
+			}
+			unquoted.WriteByte(value[i])
+		}
+		return unquoted.String()
+	default:
+		return value
+	}
+}
+
+// mergeListFlagValues merges two comma-separated lists of entries, e.g. key=value labels. The entries of the second
+// list replace the entries of the first list with the same key.
+func mergeListFlagValues(values, overrides string) string {
+	entries := []string{}
+	index := map[string]int{}
+	for _, list := range []string{values, overrides} {
+		for _, entry := range strings.Split(list, ",") {
+			if entry == "" {
+				continue
+			}
+			key := strings.SplitN(entry, "=", 2)[0]
+			if i, ok := index[key]; ok {
+				entries[i] = entry
+				continue
+			}
+			index[key] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+// AddFiles adds the files to the given cloud-config or Ignition user data, keeping the files
+// already defined in the user data. User data of any other format is returned unchanged.
+func AddFiles(userData []byte, files []File) ([]byte, error) {
+	if len(files) == 0 {
+		return userData, nil
+	}
+
+	switch {
+	case IsCloudConfig(userData):
+		return addCloudConfigFiles(userData, files)
+	case IsIgnition(userData):
+		return addIgnitionFiles(userData, files)
+	default:
+		return userData, nil
+	}
+}
+
+// AddTrustBundle adds the PEM encoded CA certificates to the system trust store of the node. For cloud-config
+// user data, the certificates are installed by the ca_certs module, which runs before the bootstrap commands.
+// For Ignition user data, they are written to the CA anchors before any service starts.
+// User data of any other format is returned unchanged.
+func AddTrustBundle(userData []byte, certificates []string) ([]byte, error) {
+	if len(certificates) == 0 {
+		return userData, nil
+	}
+
+	switch {
+	case IsCloudConfig(userData):
+		// cloud-init accepts the deprecated ca-certs key as well.
+		if !hasCloudConfigKey(userData, "ca_certs") && !hasCloudConfigKey(userData, "ca-certs") {
+			items := make([]string, 0, len(certificates))
+			for _, certificate := range certificates {
+				items = append(items, yamlScalar(certificate))
+			}
+			out := []byte(cloudConfigText(userData) + "ca_certs:\n  trusted:\n" + cloudConfigListItems(items, 2))
+			if length, ok := cloudConfigListLength(out, "ca_certs", "trusted"); ok && length == len(certificates) {
+				return out, nil
+			}
+		}
+		return updateCloudConfig(userData, func(config map[string]interface{}) {
+			key := "ca_certs"
+			if _, ok := config["ca-certs"]; ok {
+				key = "ca-certs"
+			}
+			caCerts, _ := config[key].(map[string]interface{})
+			if caCerts == nil {
+				caCerts = map[string]interface{}{}
+			}
+			trusted, _ := caCerts["trusted"].([]interface{})
+			for _, certificate := range certificates {
+				trusted = append(trusted, certificate)
+			}
+			caCerts["trusted"] = trusted
+			config[key] = caCerts
+		})
+	case IsIgnition(userData):
+		bundle := ""
+		for _, certificate := range certificates {
+			bundle += strings.TrimSuffix(certificate, "\n") + "\n"
+		}
+		return addIgnitionFiles(userData, []File{{Path: trustBundlePath, Permissions: 0644, Content: bundle}})
+	default:
+		return userData, nil
+	}
+}
+
+// fileContents returns the contents of the files written by the cloud-config or Ignition user data, by path.
+// Files of which the content cannot be decoded, e.g. gzip compressed files, are ignored.
+func fileContents(userData []byte) (map[string]string, error) {
+	contents := map[string]string{}
+	switch {
+	case IsCloudConfig(userData):
+		config := map[string]interface{}{}
+		if err := yaml.Unmarshal(userData, &config); err != nil {
+			return nil, errors.Wrap(err, "failed to parse cloud-config user data")
+		}
+		writeFiles, _ := config["write_files"].([]interface{})
+		for _, writeFile := range writeFiles {
+			file, _ := writeFile.(map[string]interface{})
+			path, _ := file["path"].(string)
+			content, _ := file["content"].(string)
+			encoding, _ := file["encoding"].(string)
+			switch encoding {
+			case "", "text/plain":
+				contents[path] = content
+			case "b64", "base64":
+				if decoded, err := base64.StdEncoding.DecodeString(content); err == nil {
+					contents[path] = string(decoded)
+				}
+			}
+		}
+	case IsIgnition(userData):
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(userData, &config); err != nil {
+			return nil, errors.Wrap(err, "failed to parse ignition user data")
+		}
+		storage, _ := config["storage"].(map[string]interface{})
+		ignitionFiles, _ := storage["files"].([]interface{})
+		for _, ignitionFile := range ignitionFiles {
+			file, _ := ignitionFile.(map[string]interface{})
+			path, _ := file["path"].(string)
+			fileContents, _ := file["contents"].(map[string]interface{})
+			source, _ := fileContents["source"].(string)
+			if content, ok := decodeDataURL(source); ok {
+				contents[path] = content
+			}
+		}
+	}
+	return contents, nil
+}
+
+// decodeDataURL decodes the data of a data URL, e.g. data:;base64,<base64 data> or data:,<percent-encoded data>.
+func decodeDataURL(source string) (string, bool) {
+	if !strings.HasPrefix(source, "data:") {
+		return "", false
+	}
+	metaData := strings.SplitN(strings.TrimPrefix(source, "data:"), ",", 2)
+	if len(metaData) != 2 {
+		return "", false
+	}
+	if strings.HasSuffix(metaData[0], ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(metaData[1])
+		return string(decoded), err == nil
+	}
+	decoded, err := url.PathUnescape(metaData[1])
+	return decoded, err == nil
+}
+
+func addCloudConfigFiles(userData []byte, files []File) ([]byte, error) {
+	items := make([]string, 0, len(files))
+	for _, file := range files {
+		items = append(items, fmt.Sprintf("path: %s\npermissions: '%#o'\nencoding: b64\ncontent: %s",
+			yamlScalar(file.Path), file.Permissions, base64.StdEncoding.EncodeToString([]byte(file.Content))))
+	}
+	if existing, ok := cloudConfigListLength(userData, "write_files"); ok {
+		if out, ok := appendCloudConfigListItems(userData, "write_files", items); ok {
+			// the user data is only edited in place when it parses back with the files added
+			if length, ok := cloudConfigListLength(out, "write_files"); ok && length == existing+len(items) {
+				return out, nil
+			}
+		}
+	}
+
+	return updateCloudConfig(userData, func(config map[string]interface{}) {
+		writeFiles, _ := config["write_files"].([]interface{})
+		for _, file := range files {
+			writeFiles = append(writeFiles, map[string]interface{}{
+				"path":        file.Path,
+				"permissions": fmt.Sprintf("%#o", file.Permissions),
+				"encoding":    "b64",
+				"content":     base64.StdEncoding.EncodeToString([]byte(file.Content)),
+			})
+		}
+		config["write_files"] = writeFiles
+	})
+}
+
+// appendCloudConfigListItems appends the YAML items to the top-level list of the cloud-config user data with the
+// given key, or adds the list when the key is not set. The rest of the user data is kept verbatim, e.g. the jinja
+// template header of the kubeadm bootstrap provider, which cloud-init requires on the first line, and comments.
+// It returns false when the list cannot be edited in place, e.g. a flow sequence.
+func appendCloudConfigListItems(userData []byte, key string, items []string) ([]byte, bool) {
+	lines := strings.SplitAfter(string(userData), "\n")
+	keyLine := -1
+	for i, line := range lines {
+		if value := strings.TrimPrefix(line, key+":"); value != line {
+			if value = strings.TrimSpace(value); value != "" && !strings.HasPrefix(value, "#") {
+				return nil, false
+			}
+			keyLine = i
+			break
+		}
+	}
+	if keyLine < 0 {
+		return []byte(cloudConfigText(userData) + key + ":\n" + cloudConfigListItems(items, 0)), true
+	}
+
+	// The items of the list are indented like its first item, possibly not at all. The list ends at the first line
+	// indented less than its items, or as much without being an item, e.g. the next top-level key.
+	end := keyLine
+	indent := -1
+	for i := keyLine + 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		lineIndent := len(lines[i]) - len(trimmed)
+		if trimmed = strings.TrimSpace(trimmed); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			// blank lines and comments are only part of the list within its items, e.g. in a block scalar.
+			if indent >= 0 && lineIndent > indent && trimmed != "" {
+				end = i
+			}
+			continue
+		}
+		isItem := trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+		if indent < 0 {
+			if !isItem {
+				if lineIndent > 0 {
+					return nil, false
+				}
+				break
+			}
+			indent = lineIndent
+		}
+		if lineIndent < indent || (lineIndent == indent && !isItem) {
+			break
+		}
+		end = i
+	}
+	if indent < 0 {
+		indent = 0
+	}
+
+	out := strings.Join(lines[:end+1], "")
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return []byte(out + cloudConfigListItems(items, indent) + strings.Join(lines[end+1:], "")), true
+}
+
+// cloudConfigListLength parses the cloud-config user data and returns the length of the list at the given nested keys,
+// zero when the list is not set. It returns false when the user data cannot be parsed or the keys do not hold a list.
+func cloudConfigListLength(userData []byte, keys ...string) (int, bool) {
+	var value interface{}
+	if err := yaml.Unmarshal(userData, &value); err != nil {
+		return 0, false
+	}
+	for _, key := range keys {
+		if value == nil {
+			return 0, true
+		}
+		config, ok := value.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		value = config[key]
+	}
+	if value == nil {
+		return 0, true
+	}
+	list, ok := value.([]interface{})
+	return len(list), ok
+}
+
+// hasCloudConfigKey checks if the top-level key is set in the cloud-config user data.
+func hasCloudConfigKey(userData []byte, key string) bool {
+	return regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:`).Match(userData)
+}
+
+// cloudConfigText returns the cloud-config user data, ending with a new line so that keys can be appended.
+func cloudConfigText(userData []byte) string {
+	text := string(userData)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text
+}
+
+// cloudConfigListItems renders the YAML items as a block sequence indented by the given number of spaces.
+func cloudConfigListItems(items []string, indent int) string {
+	padding := strings.Repeat(" ", indent)
+	out := ""
+	for _, item := range items {
+		for i, line := range strings.Split(item, "\n") {
+			if i == 0 {
+				out += padding + "- " + line + "\n"
+			} else {
+				out += padding + "  " + line + "\n"
+			}
+		}
+	}
+	return out
+}
+
+// yamlScalar renders the value as a YAML scalar: a literal block scalar for a multi-line value, e.g. a PEM encoded
+// certificate, a plain scalar for an absolute path, and a double-quoted scalar otherwise.
+func yamlScalar(value string) string {
+	if strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		chomping := "-"
+		if strings.HasSuffix(value, "\n") {
+			chomping = ""
+		}
+		return "|" + chomping + "\n" + strings.TrimSuffix(value, "\n")
+	}
+	if regexp.MustCompile(`^/[\w./-]*$`).MatchString(value) {
+		return value
+	}
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// updateCloudConfig parses the cloud-config user data, applies the given mutation and renders it back. The leading
+// comment lines, like the #cloud-config header and the jinja template header, are kept verbatim, while other
+// comments are lost. It is only used for user data which cannot be edited in place.
+func updateCloudConfig(userData []byte, mutate func(config map[string]interface{})) ([]byte, error) {
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(userData, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to parse cloud-config user data")
+	}
+
+	mutate(config)
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render cloud-config user data")
+	}
+	return append([]byte(cloudConfigHeaderLines(userData)), out...), nil
+}
+
+// cloudConfigHeaderLines returns the leading comment lines of the cloud-config user data.
+func cloudConfigHeaderLines(userData []byte) string {
+	header := ""
+	for _, line := range strings.SplitAfter(string(userData), "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		header += line
+	}
+	if !strings.Contains(header, cloudConfigHeader) {
+		return cloudConfigHeader
+	}
+	return header
+}
+
+func addIgnitionFiles(userData []byte, files []File) ([]byte, error) {
+	return updateIgnition(userData, func(config map[string]interface{}, legacy bool) {
+		storage, _ := config["storage"].(map[string]interface{})
+		if storage == nil {
+			storage = map[string]interface{}{}
+		}
+		ignitionFiles, _ := storage["files"].([]interface{})
+		for _, file := range files {
+			ignitionFile := map[string]interface{}{
+				"path": file.Path,
+				"mode": file.Permissions,
+				"contents": map[string]interface{}{
+					"source": "data:;base64," + base64.StdEncoding.EncodeToString([]byte(file.Content)),
+				},
+			}
+			if legacy {
+				ignitionFile["filesystem"] = "root"
+			} else {
+				ignitionFile["overwrite"] = true
+			}
+			ignitionFiles = append(ignitionFiles, ignitionFile)
+		}
+		storage["files"] = ignitionFiles
+		config["storage"] = storage
+	})
+}
+
+// updateIgnition parses the Ignition user data, applies the given mutation and renders it back.
+// The mutation is told whether the config uses the legacy 2.x Ignition spec.
+func updateIgnition(userData []byte, mutate func(config map[string]interface{}, legacy bool)) ([]byte, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(userData, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ignition user data")
+	}
+
+	legacy := false
+	if ignition, ok := config["ignition"].(map[string]interface{}); ok {
+		if version, ok := ignition["version"].(string); ok {
+			legacy = strings.HasPrefix(version, "2.")
+		}
+	}
+
+	mutate(config, legacy)
+
+	out, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render ignition user data")
+	}
+	return out, nil
+}
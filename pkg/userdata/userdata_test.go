@@ -0,0 +1,333 @@
+package userdata_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/cluster-api-provider-kubevirt/pkg/userdata"
+)
+
+// kubeadmJoinUserData is the cloud-config rendered by the kubeadm bootstrap provider for a worker node, with a file
+// of the KubeadmConfig setting kubelet flags.
+const kubeadmJoinUserData = `## template: jinja
+#cloud-config
+
+write_files:
+-   path: /etc/default/kubelet
+    owner: root:root
+    permissions: '0644'
+    content: |
+      # set by the KubeadmConfig
+      KUBELET_EXTRA_ARGS="--node-labels=pool=a,env=prod --max-pods=50"
+-   path: /run/kubeadm/kubeadm-join-config.yaml
+    owner: root:root
+    permissions: '0640'
+    content: |
+      ---
+      apiVersion: kubeadm.k8s.io/v1beta2
+      discovery:
+        bootstrapToken:
+          apiServerEndpoint: 10.0.0.1:6443
+          caCertHashes:
+          - sha256:45db6b9d1a1b7d5b8e4ea8fb1fb9ae0a1c5e8c1dbd2a5a3f6c1d6e2c9d4b0a1f
+          token: abcdef.0123456789abcdef
+      kind: JoinConfiguration
+      nodeRegistration:
+        kubeletExtraArgs:
+          cloud-provider: external
+        name: '{{ ds.meta_data.local_hostname }}'
+      
+-   path: /run/cluster-api/placeholder
+    owner: root:root
+    permissions: '0640'
+    content: "This placeholder file is used to create the /run/cluster-api sub directory in a way that is compatible with both Linux and Windows (mkdir -p /run/cluster-api does not work with Windows)"
+runcmd:
+  - "kubeadm join --config /run/kubeadm/kubeadm-join-config.yaml  && echo success > /run/cluster-api/bootstrap-success.complete"
+`
+
+var _ = Describe("IsCloudConfig", func() {
+	DescribeTable("should detect userdata is cloud-config", func(userData []byte, expected bool) {
+		Expect(userdata.IsCloudConfig(userData)).To(Equal(expected))
+	},
+		Entry("should detect cloud-config", []byte("#something\n\n#something else\n#cloud-config\nthe end"), true),
+		Entry("should not detect cloud-config", []byte("#something\n\n#something else\n#not-cloud-config\nthe end"), false),
+		Entry("should not detect cloud-config", []byte("#something\n\n#something else\n   #cloud-config\nthe end"), false),
+	)
+})
+
+var _ = Describe("AddFiles", func() {
+	files := []userdata.File{
+		{Path: "/etc/capk/test", Permissions: 0644, Content: "test content\n"},
+	}
+	encodedContent := base64.StdEncoding.EncodeToString([]byte("test content\n"))
+
+	It("should add files to cloud-config user data and keep existing files", func() {
+		cloudConfig := []byte("#cloud-config\nwrite_files:\n- path: /etc/existing\n  content: existing\nruncmd:\n- kubeadm join\n")
+
+		out, err := userdata.AddFiles(cloudConfig, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userdata.IsCloudConfig(out)).To(BeTrue())
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["runcmd"]).To(Equal([]interface{}{"kubeadm join"}))
+		Expect(config["write_files"]).To(HaveLen(2))
+		writeFiles := config["write_files"].([]interface{})
+		Expect(writeFiles[0]).To(HaveKeyWithValue("path", "/etc/existing"))
+		Expect(writeFiles[1]).To(HaveKeyWithValue("path", "/etc/capk/test"))
+		Expect(writeFiles[1]).To(HaveKeyWithValue("permissions", "0644"))
+		Expect(writeFiles[1]).To(HaveKeyWithValue("content", encodedContent))
+	})
+
+	It("should add files to the kubeadm user data and keep it verbatim", func() {
+		out, err := userdata.AddFiles([]byte(kubeadmJoinUserData), files)
+		Expect(err).NotTo(HaveOccurred())
+
+		added := "- path: /etc/capk/test\n" +
+			"  permissions: '0644'\n" +
+			"  encoding: b64\n" +
+			"  content: " + encodedContent + "\n"
+		Expect(string(out)).To(Equal(strings.Replace(kubeadmJoinUserData, "runcmd:\n", added+"runcmd:\n", 1)))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		writeFiles := config["write_files"].([]interface{})
+		Expect(writeFiles).To(HaveLen(4))
+		Expect(writeFiles[1]).To(HaveKeyWithValue("content", ContainSubstring("name: '{{ ds.meta_data.local_hostname }}'")))
+		Expect(writeFiles[3]).To(HaveKeyWithValue("path", "/etc/capk/test"))
+		Expect(writeFiles[3]).To(HaveKeyWithValue("content", encodedContent))
+	})
+
+	It("should add the write_files module to cloud-config user data without files", func() {
+		cloudConfig := []byte("## template: jinja\n#cloud-config\n\nruncmd:\n  - kubeadm join\n")
+
+		out, err := userdata.AddFiles(cloudConfig, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(HavePrefix(string(cloudConfig)))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["runcmd"]).To(Equal([]interface{}{"kubeadm join"}))
+		Expect(config["write_files"]).To(HaveLen(1))
+	})
+
+	It("should keep the header of cloud-config user data with a flow sequence of files", func() {
+		cloudConfig := []byte("## template: jinja\n#cloud-config\nwrite_files: [{path: /etc/existing, content: existing}]\n")
+
+		out, err := userdata.AddFiles(cloudConfig, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(HavePrefix("## template: jinja\n#cloud-config\n"))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["write_files"]).To(HaveLen(2))
+	})
+
+	It("should render cloud-config user data which can't be edited in place", func() {
+		cloudConfig := []byte("#cloud-config\n{runcmd: [kubeadm join]}\n")
+
+		out, err := userdata.AddFiles(cloudConfig, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(HavePrefix("#cloud-config\n"))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["runcmd"]).To(Equal([]interface{}{"kubeadm join"}))
+		Expect(config["write_files"]).To(HaveLen(1))
+	})
+
+	It("should fail on invalid cloud-config user data", func() {
+		_, err := userdata.AddFiles([]byte("#cloud-config\nruncmd: [kubeadm join\n"), files)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should add files to ignition user data", func() {
+		ignition := []byte(`{"ignition":{"version":"3.2.0"},"storage":{"files":[{"path":"/etc/existing"}]}}`)
+
+		out, err := userdata.AddFiles(ignition, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userdata.IsIgnition(out)).To(BeTrue())
+
+		config := map[string]interface{}{}
+		Expect(json.Unmarshal(out, &config)).To(Succeed())
+		ignitionFiles := config["storage"].(map[string]interface{})["files"].([]interface{})
+		Expect(ignitionFiles).To(HaveLen(2))
+		Expect(ignitionFiles[1]).To(HaveKeyWithValue("path", "/etc/capk/test"))
+		Expect(ignitionFiles[1]).To(HaveKeyWithValue("mode", BeNumerically("==", 0644)))
+		Expect(ignitionFiles[1]).To(HaveKeyWithValue("contents", HaveKeyWithValue("source", "data:;base64,"+encodedContent)))
+	})
+
+	It("should set the root filesystem for legacy ignition user data", func() {
+		ignition := []byte(`{"ignition":{"version":"2.3.0"}}`)
+
+		out, err := userdata.AddFiles(ignition, files)
+		Expect(err).NotTo(HaveOccurred())
+
+		config := map[string]interface{}{}
+		Expect(json.Unmarshal(out, &config)).To(Succeed())
+		ignitionFiles := config["storage"].(map[string]interface{})["files"].([]interface{})
+		Expect(ignitionFiles).To(HaveLen(1))
+		Expect(ignitionFiles[0]).To(HaveKeyWithValue("filesystem", "root"))
+	})
+
+	It("should not modify other user data formats", func() {
+		script := []byte("#!/bin/bash\nkubeadm join\n")
+
+		out, err := userdata.AddFiles(script, files)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(script))
+	})
+})
+
+var _ = Describe("KubeletExtraArgsFiles", func() {
+	It("should render sorted kubelet flags", func() {
+		files, err := userdata.KubeletExtraArgsFiles([]byte("#cloud-config\n"), map[string]string{
+			"node-labels":    "env=test",
+			"cloud-provider": "external",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		for _, file := range files {
+			Expect(file.Content).To(Equal("KUBELET_EXTRA_ARGS=\"--cloud-provider=external --node-labels=env=test\"\n"))
+		}
+	})
+
+	It("should merge the kubelet flags into the files of the user data", func() {
+		files, err := userdata.KubeletExtraArgsFiles([]byte(kubeadmJoinUserData), map[string]string{
+			"node-labels": "env=test,zone=b",
+			"v":           "2",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].Path).To(Equal("/etc/default/kubelet"))
+		Expect(files[0].Content).To(Equal("# set by the KubeadmConfig\n" +
+			"KUBELET_EXTRA_ARGS=\"--node-labels=pool=a,env=test,zone=b --max-pods=50 --v=2\"\n"))
+		Expect(files[1].Path).To(Equal("/etc/sysconfig/kubelet"))
+		Expect(files[1].Content).To(Equal("KUBELET_EXTRA_ARGS=\"--node-labels=env=test,zone=b --v=2\"\n"))
+	})
+
+	It("should merge the kubelet flags into the files of ignition user data", func() {
+		content := base64.StdEncoding.EncodeToString([]byte("KUBELET_EXTRA_ARGS=--max-pods=50\n"))
+		ignition := []byte(`{"ignition":{"version":"3.2.0"},"storage":{"files":[{"path":"/etc/sysconfig/kubelet","contents":{"source":"data:;base64,` + content + `"}}]}}`)
+
+		files, err := userdata.KubeletExtraArgsFiles(ignition, map[string]string{"max-pods": "100"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files[1].Path).To(Equal("/etc/sysconfig/kubelet"))
+		Expect(files[1].Content).To(Equal("KUBELET_EXTRA_ARGS=\"--max-pods=100\"\n"))
+	})
+
+	It("should escape the special characters of the kubelet flags", func() {
+		content := base64.StdEncoding.EncodeToString([]byte(`KUBELET_EXTRA_ARGS="--root-dir=/var/lib/\"kubelet\""` + "\n"))
+		ignition := []byte(`{"ignition":{"version":"3.2.0"},"storage":{"files":[{"path":"/etc/sysconfig/kubelet","contents":{"source":"data:;base64,` + content + `"}}]}}`)
+
+		files, err := userdata.KubeletExtraArgsFiles(ignition, map[string]string{"pod-infra-container-image": "registry.example.com/$pause`id`\\"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files[1].Content).To(Equal("KUBELET_EXTRA_ARGS=\"--root-dir=/var/lib/\\\"kubelet\\\" --pod-infra-container-image=registry.example.com/\\$pause\\`id\\`\\\\\"\n"))
+	})
+
+	It("should keep single-quoted kubelet flags", func() {
+		content := base64.StdEncoding.EncodeToString([]byte(`KUBELET_EXTRA_ARGS='--root-dir=/var/lib/"kubelet"'` + "\n"))
+		ignition := []byte(`{"ignition":{"version":"3.2.0"},"storage":{"files":[{"path":"/etc/sysconfig/kubelet","contents":{"source":"data:;base64,` + content + `"}}]}}`)
+
+		files, err := userdata.KubeletExtraArgsFiles(ignition, map[string]string{"max-pods": "100"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files[1].Content).To(Equal(`KUBELET_EXTRA_ARGS="--root-dir=/var/lib/\"kubelet\" --max-pods=100"` + "\n"))
+	})
+
+	It("should reject kubelet flags with a new line", func() {
+		_, err := userdata.KubeletExtraArgsFiles([]byte("#cloud-config\n"), map[string]string{"node-labels": "env=test\nLD_PRELOAD=/tmp/x"})
+		Expect(err).To(MatchError(ContainSubstring("must not contain a new line")))
+	})
+
+	It("should not render files without flags", func() {
+		Expect(userdata.KubeletExtraArgsFiles([]byte(kubeadmJoinUserData), nil)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("AddTrustBundle", func() {
+	certificates := []string{
+		"-----BEGIN CERTIFICATE-----\nregistry\n-----END CERTIFICATE-----\n",
+		"-----BEGIN CERTIFICATE-----\nproxy\n-----END CERTIFICATE-----",
+	}
+
+	It("should add the certificates to the ca_certs module of cloud-config user data", func() {
+		cloudConfig := []byte("#cloud-config\nca_certs:\n  trusted:\n  - existing\nruncmd:\n- kubeadm join\n")
+
+		out, err := userdata.AddTrustBundle(cloudConfig, certificates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userdata.IsCloudConfig(out)).To(BeTrue())
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["runcmd"]).To(Equal([]interface{}{"kubeadm join"}))
+		Expect(config["ca_certs"]).To(HaveKeyWithValue("trusted", []interface{}{"existing", certificates[0], certificates[1]}))
+	})
+
+	It("should add the ca_certs module to the kubeadm user data and keep it verbatim", func() {
+		out, err := userdata.AddTrustBundle([]byte(kubeadmJoinUserData), certificates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(kubeadmJoinUserData + "ca_certs:\n" +
+			"  trusted:\n" +
+			"  - |\n" +
+			"    -----BEGIN CERTIFICATE-----\n" +
+			"    registry\n" +
+			"    -----END CERTIFICATE-----\n" +
+			"  - |-\n" +
+			"    -----BEGIN CERTIFICATE-----\n" +
+			"    proxy\n" +
+			"    -----END CERTIFICATE-----\n"))
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["ca_certs"]).To(HaveKeyWithValue("trusted", []interface{}{certificates[0], certificates[1]}))
+	})
+
+	It("should keep the header of cloud-config user data with ca_certs", func() {
+		cloudConfig := []byte("## template: jinja\n#cloud-config\nca_certs:\n  trusted:\n  - existing\n")
+
+		out, err := userdata.AddTrustBundle(cloudConfig, certificates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(HavePrefix("## template: jinja\n#cloud-config\n"))
+	})
+
+	It("should render cloud-config user data to which ca_certs can't be appended", func() {
+		cloudConfig := []byte("#cloud-config\n{runcmd: [kubeadm join]}\n")
+
+		out, err := userdata.AddTrustBundle(cloudConfig, certificates)
+		Expect(err).NotTo(HaveOccurred())
+
+		config := map[string]interface{}{}
+		Expect(yaml.Unmarshal(out, &config)).To(Succeed())
+		Expect(config["runcmd"]).To(Equal([]interface{}{"kubeadm join"}))
+		Expect(config["ca_certs"]).To(HaveKeyWithValue("trusted", []interface{}{certificates[0], certificates[1]}))
+	})
+
+	It("should write the certificates to the CA anchors of ignition user data", func() {
+		ignition := []byte(`{"ignition":{"version":"3.2.0"}}`)
+
+		out, err := userdata.AddTrustBundle(ignition, certificates)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userdata.IsIgnition(out)).To(BeTrue())
+
+		config := map[string]interface{}{}
+		Expect(json.Unmarshal(out, &config)).To(Succeed())
+		ignitionFiles := config["storage"].(map[string]interface{})["files"].([]interface{})
+		Expect(ignitionFiles).To(HaveLen(1))
+		Expect(ignitionFiles[0]).To(HaveKeyWithValue("path", "/etc/pki/ca-trust/source/anchors/capk-trust-bundle.pem"))
+		bundle := base64.StdEncoding.EncodeToString([]byte(certificates[0] + certificates[1] + "\n"))
+		Expect(ignitionFiles[0]).To(HaveKeyWithValue("contents", HaveKeyWithValue("source", "data:;base64,"+bundle)))
+	})
+
+	It("should not modify the user data without certificates", func() {
+		cloudConfig := []byte("#cloud-config\nruncmd:\n- kubeadm join\n")
+
+		out, err := userdata.AddTrustBundle(cloudConfig, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(Equal(cloudConfig))
+	})
+})